@@ -0,0 +1,4 @@
+// Package api contains the gRPC service of geo2tz, generated from geo2tz.proto
+package api
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative geo2tz.proto
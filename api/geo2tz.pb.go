@@ -0,0 +1,554 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: geo2tz.proto
+
+package api
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type LookupRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id is an optional identifier returned as is in the response
+	Id            string  `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Lat           float64 `protobuf:"fixed64,2,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lon           float64 `protobuf:"fixed64,3,opt,name=lon,proto3" json:"lon,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupRequest) Reset() {
+	*x = LookupRequest{}
+	mi := &file_geo2tz_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupRequest) ProtoMessage() {}
+
+func (x *LookupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_geo2tz_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupRequest.ProtoReflect.Descriptor instead.
+func (*LookupRequest) Descriptor() ([]byte, []int) {
+	return file_geo2tz_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LookupRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *LookupRequest) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *LookupRequest) GetLon() float64 {
+	if x != nil {
+		return x.Lon
+	}
+	return 0
+}
+
+type LookupResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Tz    string                 `protobuf:"bytes,2,opt,name=tz,proto3" json:"tz,omitempty"`
+	Lat   float64                `protobuf:"fixed64,3,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lon   float64                `protobuf:"fixed64,4,opt,name=lon,proto3" json:"lon,omitempty"`
+	// override is true when the coordinates are in one of the custom override zones
+	Override bool `protobuf:"varint,5,opt,name=override,proto3" json:"override,omitempty"`
+	// fallback is true when the coordinates are outside all the zones and the closest one is returned
+	Fallback bool `protobuf:"varint,6,opt,name=fallback,proto3" json:"fallback,omitempty"`
+	// distance_meters is the distance from the closest zone, for fallback responses
+	DistanceMeters float64 `protobuf:"fixed64,7,opt,name=distance_meters,json=distanceMeters,proto3" json:"distance_meters,omitempty"`
+	// nautical is true when the zone is computed from the longitude
+	Nautical bool `protobuf:"varint,8,opt,name=nautical,proto3" json:"nautical,omitempty"`
+	// error is set, instead of tz, when the coordinates are invalid or their timezone is not found
+	Error         *Error `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupResponse) Reset() {
+	*x = LookupResponse{}
+	mi := &file_geo2tz_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupResponse) ProtoMessage() {}
+
+func (x *LookupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_geo2tz_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupResponse.ProtoReflect.Descriptor instead.
+func (*LookupResponse) Descriptor() ([]byte, []int) {
+	return file_geo2tz_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LookupResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *LookupResponse) GetTz() string {
+	if x != nil {
+		return x.Tz
+	}
+	return ""
+}
+
+func (x *LookupResponse) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *LookupResponse) GetLon() float64 {
+	if x != nil {
+		return x.Lon
+	}
+	return 0
+}
+
+func (x *LookupResponse) GetOverride() bool {
+	if x != nil {
+		return x.Override
+	}
+	return false
+}
+
+func (x *LookupResponse) GetFallback() bool {
+	if x != nil {
+		return x.Fallback
+	}
+	return false
+}
+
+func (x *LookupResponse) GetDistanceMeters() float64 {
+	if x != nil {
+		return x.DistanceMeters
+	}
+	return 0
+}
+
+func (x *LookupResponse) GetNautical() bool {
+	if x != nil {
+		return x.Nautical
+	}
+	return false
+}
+
+func (x *LookupResponse) GetError() *Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+type Error struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// code is the gRPC status code of the error
+	Code          uint32 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_geo2tz_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_geo2tz_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_geo2tz_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Error) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type BatchLookupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*LookupRequest       `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchLookupRequest) Reset() {
+	*x = BatchLookupRequest{}
+	mi := &file_geo2tz_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchLookupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchLookupRequest) ProtoMessage() {}
+
+func (x *BatchLookupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_geo2tz_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchLookupRequest.ProtoReflect.Descriptor instead.
+func (*BatchLookupRequest) Descriptor() ([]byte, []int) {
+	return file_geo2tz_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchLookupRequest) GetItems() []*LookupRequest {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type BatchLookupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*LookupResponse      `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchLookupResponse) Reset() {
+	*x = BatchLookupResponse{}
+	mi := &file_geo2tz_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchLookupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchLookupResponse) ProtoMessage() {}
+
+func (x *BatchLookupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_geo2tz_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchLookupResponse.ProtoReflect.Descriptor instead.
+func (*BatchLookupResponse) Descriptor() ([]byte, []int) {
+	return file_geo2tz_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchLookupResponse) GetItems() []*LookupResponse {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type VersionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VersionRequest) Reset() {
+	*x = VersionRequest{}
+	mi := &file_geo2tz_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VersionRequest) ProtoMessage() {}
+
+func (x *VersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_geo2tz_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VersionRequest.ProtoReflect.Descriptor instead.
+func (*VersionRequest) Descriptor() ([]byte, []int) {
+	return file_geo2tz_proto_rawDescGZIP(), []int{5}
+}
+
+type VersionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	GeoDataUrl    string                 `protobuf:"bytes,3,opt,name=geo_data_url,json=geoDataUrl,proto3" json:"geo_data_url,omitempty"`
+	Variant       string                 `protobuf:"bytes,4,opt,name=variant,proto3" json:"variant,omitempty"`
+	Sha256        string                 `protobuf:"bytes,5,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VersionResponse) Reset() {
+	*x = VersionResponse{}
+	mi := &file_geo2tz_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VersionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VersionResponse) ProtoMessage() {}
+
+func (x *VersionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_geo2tz_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VersionResponse.ProtoReflect.Descriptor instead.
+func (*VersionResponse) Descriptor() ([]byte, []int) {
+	return file_geo2tz_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *VersionResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *VersionResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *VersionResponse) GetGeoDataUrl() string {
+	if x != nil {
+		return x.GeoDataUrl
+	}
+	return ""
+}
+
+func (x *VersionResponse) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+func (x *VersionResponse) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+var File_geo2tz_proto protoreflect.FileDescriptor
+
+const file_geo2tz_proto_rawDesc = "" +
+	"\n" +
+	"\fgeo2tz.proto\x12\tgeo2tz.v1\"C\n" +
+	"\rLookupRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03lat\x18\x02 \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lon\x18\x03 \x01(\x01R\x03lon\"\xf9\x01\n" +
+	"\x0eLookupResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x0e\n" +
+	"\x02tz\x18\x02 \x01(\tR\x02tz\x12\x10\n" +
+	"\x03lat\x18\x03 \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lon\x18\x04 \x01(\x01R\x03lon\x12\x1a\n" +
+	"\boverride\x18\x05 \x01(\bR\boverride\x12\x1a\n" +
+	"\bfallback\x18\x06 \x01(\bR\bfallback\x12'\n" +
+	"\x0fdistance_meters\x18\a \x01(\x01R\x0edistanceMeters\x12\x1a\n" +
+	"\bnautical\x18\b \x01(\bR\bnautical\x12&\n" +
+	"\x05error\x18\t \x01(\v2\x10.geo2tz.v1.ErrorR\x05error\"5\n" +
+	"\x05Error\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\rR\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"D\n" +
+	"\x12BatchLookupRequest\x12.\n" +
+	"\x05items\x18\x01 \x03(\v2\x18.geo2tz.v1.LookupRequestR\x05items\"F\n" +
+	"\x13BatchLookupResponse\x12/\n" +
+	"\x05items\x18\x01 \x03(\v2\x19.geo2tz.v1.LookupResponseR\x05items\"\x10\n" +
+	"\x0eVersionRequest\"\x91\x01\n" +
+	"\x0fVersionResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12 \n" +
+	"\fgeo_data_url\x18\x03 \x01(\tR\n" +
+	"geoDataUrl\x12\x18\n" +
+	"\avariant\x18\x04 \x01(\tR\avariant\x12\x16\n" +
+	"\x06sha256\x18\x05 \x01(\tR\x06sha2562\xa0\x02\n" +
+	"\x06Geo2Tz\x12=\n" +
+	"\x06Lookup\x12\x18.geo2tz.v1.LookupRequest\x1a\x19.geo2tz.v1.LookupResponse\x12L\n" +
+	"\vBatchLookup\x12\x1d.geo2tz.v1.BatchLookupRequest\x1a\x1e.geo2tz.v1.BatchLookupResponse\x12G\n" +
+	"\fStreamLookup\x12\x18.geo2tz.v1.LookupRequest\x1a\x19.geo2tz.v1.LookupResponse(\x010\x01\x12@\n" +
+	"\aVersion\x12\x19.geo2tz.v1.VersionRequest\x1a\x1a.geo2tz.v1.VersionResponseB'Z%github.com/noandrea/geo2tz/v2/api;apib\x06proto3"
+
+var (
+	file_geo2tz_proto_rawDescOnce sync.Once
+	file_geo2tz_proto_rawDescData []byte
+)
+
+func file_geo2tz_proto_rawDescGZIP() []byte {
+	file_geo2tz_proto_rawDescOnce.Do(func() {
+		file_geo2tz_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_geo2tz_proto_rawDesc), len(file_geo2tz_proto_rawDesc)))
+	})
+	return file_geo2tz_proto_rawDescData
+}
+
+var file_geo2tz_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_geo2tz_proto_goTypes = []any{
+	(*LookupRequest)(nil),       // 0: geo2tz.v1.LookupRequest
+	(*LookupResponse)(nil),      // 1: geo2tz.v1.LookupResponse
+	(*Error)(nil),               // 2: geo2tz.v1.Error
+	(*BatchLookupRequest)(nil),  // 3: geo2tz.v1.BatchLookupRequest
+	(*BatchLookupResponse)(nil), // 4: geo2tz.v1.BatchLookupResponse
+	(*VersionRequest)(nil),      // 5: geo2tz.v1.VersionRequest
+	(*VersionResponse)(nil),     // 6: geo2tz.v1.VersionResponse
+}
+var file_geo2tz_proto_depIdxs = []int32{
+	2, // 0: geo2tz.v1.LookupResponse.error:type_name -> geo2tz.v1.Error
+	0, // 1: geo2tz.v1.BatchLookupRequest.items:type_name -> geo2tz.v1.LookupRequest
+	1, // 2: geo2tz.v1.BatchLookupResponse.items:type_name -> geo2tz.v1.LookupResponse
+	0, // 3: geo2tz.v1.Geo2Tz.Lookup:input_type -> geo2tz.v1.LookupRequest
+	3, // 4: geo2tz.v1.Geo2Tz.BatchLookup:input_type -> geo2tz.v1.BatchLookupRequest
+	0, // 5: geo2tz.v1.Geo2Tz.StreamLookup:input_type -> geo2tz.v1.LookupRequest
+	5, // 6: geo2tz.v1.Geo2Tz.Version:input_type -> geo2tz.v1.VersionRequest
+	1, // 7: geo2tz.v1.Geo2Tz.Lookup:output_type -> geo2tz.v1.LookupResponse
+	4, // 8: geo2tz.v1.Geo2Tz.BatchLookup:output_type -> geo2tz.v1.BatchLookupResponse
+	1, // 9: geo2tz.v1.Geo2Tz.StreamLookup:output_type -> geo2tz.v1.LookupResponse
+	6, // 10: geo2tz.v1.Geo2Tz.Version:output_type -> geo2tz.v1.VersionResponse
+	7, // [7:11] is the sub-list for method output_type
+	3, // [3:7] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_geo2tz_proto_init() }
+func file_geo2tz_proto_init() {
+	if File_geo2tz_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_geo2tz_proto_rawDesc), len(file_geo2tz_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_geo2tz_proto_goTypes,
+		DependencyIndexes: file_geo2tz_proto_depIdxs,
+		MessageInfos:      file_geo2tz_proto_msgTypes,
+	}.Build()
+	File_geo2tz_proto = out.File
+	file_geo2tz_proto_goTypes = nil
+	file_geo2tz_proto_depIdxs = nil
+}
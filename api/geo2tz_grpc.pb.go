@@ -0,0 +1,246 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: geo2tz.proto
+
+package api
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Geo2Tz_Lookup_FullMethodName       = "/geo2tz.v1.Geo2Tz/Lookup"
+	Geo2Tz_BatchLookup_FullMethodName  = "/geo2tz.v1.Geo2Tz/BatchLookup"
+	Geo2Tz_StreamLookup_FullMethodName = "/geo2tz.v1.Geo2Tz/StreamLookup"
+	Geo2Tz_Version_FullMethodName      = "/geo2tz.v1.Geo2Tz/Version"
+)
+
+// Geo2TzClient is the client API for Geo2Tz service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Geo2Tz resolves the timezone of geographic coordinates
+type Geo2TzClient interface {
+	// Lookup returns the timezone of a pair of coordinates
+	Lookup(ctx context.Context, in *LookupRequest, opts ...grpc.CallOption) (*LookupResponse, error)
+	// BatchLookup returns the timezones of a list of coordinates,
+	// the errors of the single coordinates are reported in the responses
+	BatchLookup(ctx context.Context, in *BatchLookupRequest, opts ...grpc.CallOption) (*BatchLookupResponse, error)
+	// StreamLookup returns a response for each of the coordinates received,
+	// in the same order, the errors of the single coordinates are reported in the responses
+	StreamLookup(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LookupRequest, LookupResponse], error)
+	// Version returns the release of the timezone database in use
+	Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error)
+}
+
+type geo2TzClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGeo2TzClient(cc grpc.ClientConnInterface) Geo2TzClient {
+	return &geo2TzClient{cc}
+}
+
+func (c *geo2TzClient) Lookup(ctx context.Context, in *LookupRequest, opts ...grpc.CallOption) (*LookupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LookupResponse)
+	err := c.cc.Invoke(ctx, Geo2Tz_Lookup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geo2TzClient) BatchLookup(ctx context.Context, in *BatchLookupRequest, opts ...grpc.CallOption) (*BatchLookupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchLookupResponse)
+	err := c.cc.Invoke(ctx, Geo2Tz_BatchLookup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geo2TzClient) StreamLookup(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LookupRequest, LookupResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Geo2Tz_ServiceDesc.Streams[0], Geo2Tz_StreamLookup_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LookupRequest, LookupResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Geo2Tz_StreamLookupClient = grpc.BidiStreamingClient[LookupRequest, LookupResponse]
+
+func (c *geo2TzClient) Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VersionResponse)
+	err := c.cc.Invoke(ctx, Geo2Tz_Version_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Geo2TzServer is the server API for Geo2Tz service.
+// All implementations must embed UnimplementedGeo2TzServer
+// for forward compatibility.
+//
+// Geo2Tz resolves the timezone of geographic coordinates
+type Geo2TzServer interface {
+	// Lookup returns the timezone of a pair of coordinates
+	Lookup(context.Context, *LookupRequest) (*LookupResponse, error)
+	// BatchLookup returns the timezones of a list of coordinates,
+	// the errors of the single coordinates are reported in the responses
+	BatchLookup(context.Context, *BatchLookupRequest) (*BatchLookupResponse, error)
+	// StreamLookup returns a response for each of the coordinates received,
+	// in the same order, the errors of the single coordinates are reported in the responses
+	StreamLookup(grpc.BidiStreamingServer[LookupRequest, LookupResponse]) error
+	// Version returns the release of the timezone database in use
+	Version(context.Context, *VersionRequest) (*VersionResponse, error)
+	mustEmbedUnimplementedGeo2TzServer()
+}
+
+// UnimplementedGeo2TzServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGeo2TzServer struct{}
+
+func (UnimplementedGeo2TzServer) Lookup(context.Context, *LookupRequest) (*LookupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Lookup not implemented")
+}
+func (UnimplementedGeo2TzServer) BatchLookup(context.Context, *BatchLookupRequest) (*BatchLookupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchLookup not implemented")
+}
+func (UnimplementedGeo2TzServer) StreamLookup(grpc.BidiStreamingServer[LookupRequest, LookupResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamLookup not implemented")
+}
+func (UnimplementedGeo2TzServer) Version(context.Context, *VersionRequest) (*VersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Version not implemented")
+}
+func (UnimplementedGeo2TzServer) mustEmbedUnimplementedGeo2TzServer() {}
+func (UnimplementedGeo2TzServer) testEmbeddedByValue()                {}
+
+// UnsafeGeo2TzServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to Geo2TzServer will
+// result in compilation errors.
+type UnsafeGeo2TzServer interface {
+	mustEmbedUnimplementedGeo2TzServer()
+}
+
+func RegisterGeo2TzServer(s grpc.ServiceRegistrar, srv Geo2TzServer) {
+	// If the following call pancis, it indicates UnimplementedGeo2TzServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Geo2Tz_ServiceDesc, srv)
+}
+
+func _Geo2Tz_Lookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Geo2TzServer).Lookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geo2Tz_Lookup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Geo2TzServer).Lookup(ctx, req.(*LookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Geo2Tz_BatchLookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchLookupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Geo2TzServer).BatchLookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geo2Tz_BatchLookup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Geo2TzServer).BatchLookup(ctx, req.(*BatchLookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Geo2Tz_StreamLookup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(Geo2TzServer).StreamLookup(&grpc.GenericServerStream[LookupRequest, LookupResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Geo2Tz_StreamLookupServer = grpc.BidiStreamingServer[LookupRequest, LookupResponse]
+
+func _Geo2Tz_Version_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(Geo2TzServer).Version(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geo2Tz_Version_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(Geo2TzServer).Version(ctx, req.(*VersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Geo2Tz_ServiceDesc is the grpc.ServiceDesc for Geo2Tz service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Geo2Tz_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "geo2tz.v1.Geo2Tz",
+	HandlerType: (*Geo2TzServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Lookup",
+			Handler:    _Geo2Tz_Lookup_Handler,
+		},
+		{
+			MethodName: "BatchLookup",
+			Handler:    _Geo2Tz_BatchLookup_Handler,
+		},
+		{
+			MethodName: "Version",
+			Handler:    _Geo2Tz_Version_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamLookup",
+			Handler:       _Geo2Tz_StreamLookup_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "geo2tz.proto",
+}
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/noandrea/geo2tz/v2/db"
+	"github.com/noandrea/geo2tz/v2/web"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// annotateTzColumn is the name of the timezone column appended to the csv
+	annotateTzColumn = "tz"
+	// annotateOffsetColumn is the name of the utc offset column appended to the csv
+	annotateOffsetColumn = "utc_offset"
+)
+
+// annotateOptions are the options of the annotate command
+type annotateOptions struct {
+	latColumn string
+	lonColumn string
+	noHeader  bool
+	delimiter string
+	offset    bool
+	at        string
+	output    string
+	workers   int
+}
+
+var (
+	annotateDB   string
+	annotateOpts annotateOptions
+)
+
+// annotateCmd represents the annotate command
+var annotateCmd = &cobra.Command{
+	Use:   "annotate [FILE]",
+	Short: "Annotate a CSV file with the timezone of its coordinates",
+	Long: `Read a CSV file, or the standard input, and write it back with the timezone of the
+coordinates of each row appended as a new column, without running the server.
+The latitude and longitude columns are selected by name or by position, starting from 1.
+The rows that cannot be resolved are written with an empty timezone, the malformed rows
+are not written; both are reported on the standard error and the command fails if there are any.`,
+	Example: `To annotate a file with the lat and lon columns:
+geo2tz annotate fixes.csv > fixes_tz.csv
+
+To annotate the standard input, selecting the columns and adding the utc offset:
+cat fixes.csv | geo2tz annotate --lat latitude --lon longitude --offset
+
+To annotate a file without header, with the coordinates in the 3rd and 4th columns:
+geo2tz annotate --no-header --lat 3 --lon 4 --output fixes_tz.csv fixes.csv
+`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in := cmd.InOrStdin()
+		if len(args) > 0 && args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+		out := cmd.OutOrStdout()
+		if annotateOpts.output != "" {
+			f, err := os.Create(annotateOpts.output)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := f.Close(); err != nil {
+					fmt.Println("Error closing file:", err)
+				}
+			}()
+			out = f
+		}
+		config := settings.Tz
+		if annotateDB != "" {
+			config.DatabaseName = annotateDB
+		}
+		start := time.Now()
+		tzDB, err := web.LoadDatabase(config)
+		if err != nil {
+			return fmt.Errorf("error loading the database %s: %w", config.DatabaseName, err)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "loaded %s in %v\n", config.DatabaseName, time.Since(start).Round(time.Millisecond))
+		return annotate(tzDB, in, out, cmd.ErrOrStderr(), annotateOpts)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(annotateCmd)
+	annotateCmd.Flags().StringVar(&annotateDB, "db", "", "Database filename, defaults to the configured one")
+	annotateCmd.Flags().StringVar(&annotateOpts.latColumn, "lat", "lat", "Name or position of the latitude column")
+	annotateCmd.Flags().StringVar(&annotateOpts.lonColumn, "lon", "lon", "Name or position of the longitude column")
+	annotateCmd.Flags().BoolVar(&annotateOpts.noHeader, "no-header", false, "The CSV has no header row, the columns are selected by position")
+	annotateCmd.Flags().StringVar(&annotateOpts.delimiter, "delimiter", ",", "Field delimiter of the CSV")
+	annotateCmd.Flags().BoolVar(&annotateOpts.offset, "offset", false, "Append the utc offset of the timezone as well")
+	annotateCmd.Flags().StringVar(&annotateOpts.at, "at", "", "RFC 3339 instant of the utc offset, defaults to now")
+	annotateCmd.Flags().StringVarP(&annotateOpts.output, "output", "o", "", "Output filename, defaults to the standard output")
+	annotateCmd.Flags().IntVar(&annotateOpts.workers, "workers", runtime.NumCPU(), "Number of rows resolved in parallel")
+}
+
+// annotateRow is a csv row being annotated
+type annotateRow struct {
+	line   int
+	record []string
+	tz     string
+	offset string
+	err    error
+	// done is closed when the row is annotated
+	done chan struct{}
+}
+
+// annotate reads the csv rows from in and writes them to out with the timezone of their
+// coordinates, the rows are resolved by a pool of workers and written in the input order
+// the rows that fail are reported to errOut and written without the timezone
+func annotate(tzDB db.TzDBIndex, in io.Reader, out, errOut io.Writer, opts annotateOptions) error {
+	delimiter, size := utf8.DecodeRuneInString(opts.delimiter)
+	if size == 0 || size != len(opts.delimiter) {
+		return fmt.Errorf("invalid delimiter %q, a single character is required", opts.delimiter)
+	}
+	at := time.Now()
+	if opts.at != "" {
+		var err error
+		if at, err = time.Parse(time.RFC3339, opts.at); err != nil {
+			return fmt.Errorf("invalid instant %q, a RFC 3339 timestamp is required (eg. 2024-03-31T01:30:00Z)", opts.at)
+		}
+	}
+	workers := max(opts.workers, 1)
+
+	r := csv.NewReader(in)
+	r.Comma = delimiter
+	// the rows are written back as they are, whatever the number of fields
+	r.FieldsPerRecord = -1
+	w := csv.NewWriter(out)
+	w.Comma = delimiter
+
+	// find the coordinates columns
+	var header []string
+	if !opts.noHeader {
+		var err error
+		if header, err = r.Read(); err != nil {
+			return fmt.Errorf("error reading the csv header: %w", err)
+		}
+		// the byte order mark of the files exported by spreadsheets is not part of the name
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	latIndex, err := columnIndex(header, opts.latColumn)
+	if err != nil {
+		return err
+	}
+	lonIndex, err := columnIndex(header, opts.lonColumn)
+	if err != nil {
+		return err
+	}
+	if header != nil {
+		header = append(header, annotateTzColumn)
+		if opts.offset {
+			header = append(header, annotateOffsetColumn)
+		}
+		if err = w.Write(header); err != nil {
+			return err
+		}
+	}
+
+	// the rows are queued in the input order, and written as soon as they are resolved
+	jobs := make(chan *annotateRow)
+	pending := make(chan *annotateRow, 2*workers)
+	// stop is closed when the annotation returns, so the reader and the workers
+	// are not left blocked if it returns before all the rows are written
+	stop := make(chan struct{})
+	defer close(stop)
+	var readErr error
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		for {
+			record, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			row := &annotateRow{record: record, err: err, done: make(chan struct{})}
+			var parseErr *csv.ParseError
+			switch {
+			case err == nil:
+				row.line, _ = r.FieldPos(0)
+				select {
+				case jobs <- row:
+				case <-stop:
+					return
+				}
+			case errors.As(err, &parseErr):
+				// the fields read before the error are partial, the row is not written back
+				row.line, row.record = parseErr.StartLine, nil
+				close(row.done)
+			default:
+				// the input cannot be read anymore
+				readErr = err
+				return
+			}
+			select {
+			case pending <- row:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	// the locations are loaded once for all the rows
+	var locations sync.Map
+	for range workers {
+		go func() {
+			for row := range jobs {
+				row.tz, row.err = resolveRow(tzDB, row.record, latIndex, lonIndex)
+				if row.err == nil && opts.offset {
+					row.offset, row.err = utcOffset(&locations, row.tz, at)
+				}
+				close(row.done)
+			}
+		}()
+	}
+
+	rows, failed := 0, 0
+	for row := range pending {
+		<-row.done
+		rows++
+		if row.err != nil {
+			failed++
+			fmt.Fprintf(errOut, "line %d: %v\n", row.line, row.err)
+			// rows that cannot be parsed have no fields to write back
+			if row.record == nil {
+				continue
+			}
+		}
+		record := append(row.record, row.tz)
+		if opts.offset {
+			record = append(record, row.offset)
+		}
+		if err = w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		return err
+	}
+	if readErr != nil {
+		return fmt.Errorf("error reading the csv: %w", readErr)
+	}
+	fmt.Fprintf(errOut, "annotated %d rows, %d failed\n", rows-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d rows could not be annotated", failed)
+	}
+	return nil
+}
+
+// columnIndex returns the index of a column from its name in the header or its position, starting from 1
+func columnIndex(header []string, column string) (int, error) {
+	for i, name := range header {
+		if name == column {
+			return i, nil
+		}
+	}
+	if position, err := strconv.Atoi(column); err == nil && position > 0 {
+		return position - 1, nil
+	}
+	if header == nil {
+		return 0, fmt.Errorf("invalid column %q, the position of the column is required without header (eg. 1)", column)
+	}
+	return 0, fmt.Errorf("column %q not found in the csv header", column)
+}
+
+// resolveRow returns the timezone of the coordinates in a csv row
+func resolveRow(tzDB db.TzDBIndex, record []string, latIndex, lonIndex int) (string, error) {
+	if latIndex >= len(record) || lonIndex >= len(record) {
+		return "", fmt.Errorf("missing coordinates, the row has %d fields", len(record))
+	}
+	lat, lon, err := web.ParseLatLon(record[latIndex], record[lonIndex])
+	if err != nil {
+		return "", err
+	}
+	res, err := tzDB.Resolve(lat, lon)
+	if errors.Is(err, db.ErrNotFound) {
+		return "", fmt.Errorf("timezone not found for coordinates %f,%f", lat, lon)
+	}
+	return res.TzID, err
+}
+
+// utcOffset returns the utc offset of a timezone at the given instant, formatted as +02:00
+func utcOffset(locations *sync.Map, tz string, at time.Time) (string, error) {
+	loc, ok := locations.Load(tz)
+	if !ok {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return "", fmt.Errorf("utc offset not available for %s: %w", tz, err)
+		}
+		loc, _ = locations.LoadOrStore(tz, l)
+	}
+	return at.In(loc.(*time.Location)).Format("-07:00"), nil
+}
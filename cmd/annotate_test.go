@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/noandrea/geo2tz/v2/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// testFeatures are two squares around Rome and Tokyo
+const testFeatures = `{"type":"Feature","properties":{"tzid":"Europe/Rome"},"geometry":{"type":"Polygon","coordinates":[` +
+	`[[12,41],[13,41],[13,42],[12,42],[12,41]]]}}` + "\n" +
+	`{"type":"Feature","properties":{"tzid":"Asia/Tokyo"},"geometry":{"type":"Polygon","coordinates":[` +
+	`[[139,35],[140,35],[140,36],[139,36],[139,35]]]}}`
+
+func newTestIndex(t *testing.T) *db.Geo2TzRTreeIndex {
+	tzDB, err := db.NewGeo2TzRTreeIndexFromReader(strings.NewReader(testFeatures))
+	assert.NoError(t, err)
+	return tzDB
+}
+
+func Test_annotate(t *testing.T) {
+	tzDB := newTestIndex(t)
+
+	tests := []struct {
+		name       string
+		in         string
+		opts       annotateOptions
+		want       string
+		wantErrOut []string
+		wantErr    bool
+	}{
+		{
+			"rows in the input order",
+			"id,lat,lon\n1,41.5,12.5\n2,35.5,139.5\n3,41.6,12.6\n4,35.6,139.6\n",
+			annotateOptions{latColumn: "lat", lonColumn: "lon", workers: 3},
+			"id,lat,lon,tz\n1,41.5,12.5,Europe/Rome\n2,35.5,139.5,Asia/Tokyo\n3,41.6,12.6,Europe/Rome\n4,35.6,139.6,Asia/Tokyo\n",
+			[]string{"annotated 4 rows, 0 failed"},
+			false,
+		},
+		{
+			"offset",
+			"\ufefflatitude;longitude\n41.5;12.5\n35.5;139.5\n",
+			annotateOptions{latColumn: "latitude", lonColumn: "longitude", delimiter: ";", offset: true, at: "2024-01-15T12:00:00Z", workers: 2},
+			"latitude;longitude;tz;utc_offset\n41.5;12.5;Europe/Rome;+01:00\n35.5;139.5;Asia/Tokyo;+09:00\n",
+			nil,
+			false,
+		},
+		{
+			"no header",
+			"x,41.5,12.5\n",
+			annotateOptions{latColumn: "2", lonColumn: "3", noHeader: true, workers: 1},
+			"x,41.5,12.5,Europe/Rome\n",
+			nil,
+			false,
+		},
+		{
+			"lookup errors are written without the timezone",
+			"id,lat,lon\n1,91,12.5\n2,0,0\n3,41.5\n4,41.5,12.5\n",
+			annotateOptions{latColumn: "lat", lonColumn: "lon", workers: 2},
+			"id,lat,lon,tz\n1,91,12.5,\n2,0,0,\n3,41.5,\n4,41.5,12.5,Europe/Rome\n",
+			[]string{
+				"line 2: lat value 91 out of range (-90/+90)",
+				"line 3: timezone not found for coordinates 0.000000,0.000000",
+				"line 4: missing coordinates, the row has 2 fields",
+				"annotated 1 rows, 3 failed",
+			},
+			true,
+		},
+		{
+			"malformed rows are not written",
+			"id,lat,lon\n1,41.5,12.5\n3,\"bad\n",
+			annotateOptions{latColumn: "lat", lonColumn: "lon", workers: 2},
+			"id,lat,lon,tz\n1,41.5,12.5,Europe/Rome\n",
+			[]string{"line 3: parse error", "annotated 1 rows, 1 failed"},
+			true,
+		},
+		{
+			"column not found",
+			"id,lat,lon\n",
+			annotateOptions{latColumn: "latitude", lonColumn: "lon"},
+			"",
+			nil,
+			true,
+		},
+		{
+			"column name without header",
+			"41.5,12.5\n",
+			annotateOptions{latColumn: "lat", lonColumn: "lon", noHeader: true},
+			"",
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.opts.delimiter == "" {
+				tt.opts.delimiter = ","
+			}
+			var out, errOut bytes.Buffer
+			err := annotate(tzDB, strings.NewReader(tt.in), &out, &errOut, tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, out.String())
+			for _, line := range tt.wantErrOut {
+				assert.Contains(t, errOut.String(), line)
+			}
+		})
+	}
+}
+
+// failingWriter fails all the writes
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func Test_annotateWriteError(t *testing.T) {
+	tzDB := newTestIndex(t)
+	goroutines := runtime.NumGoroutine()
+
+	// the rows do not fit in the buffer of the writer, so the write fails before the end of the input
+	in := "lat,lon\n" + strings.Repeat("41.9,12.5\n", 10000)
+	var errOut bytes.Buffer
+	err := annotate(tzDB, strings.NewReader(in), failingWriter{}, &errOut, annotateOptions{
+		latColumn: "lat",
+		lonColumn: "lon",
+		delimiter: ",",
+		workers:   4,
+	})
+	assert.EqualError(t, err, "disk full")
+	// the reader and the workers are stopped
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+}
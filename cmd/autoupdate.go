@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/noandrea/geo2tz/v2/web"
+)
+
+// autoUpdate checks for a new release of the timezone data at startup and then periodically,
+// installs it and reloads the server database, until the context is done
+func autoUpdate(ctx context.Context, server *web.Server, config web.TzSchema) {
+	// the first check runs at startup, so the status is reported without waiting for an interval
+	status := checkForUpdate(server, config, web.UpdateStatus{Interval: config.AutoUpdateInterval.String()})
+	server.SetUpdateStatus(status)
+
+	ticker := time.NewTicker(config.AutoUpdateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			status = checkForUpdate(server, config, status)
+			server.SetUpdateStatus(status)
+		}
+	}
+}
+
+// checkForUpdate installs the latest release if it differs from the one in use
+// and returns the updated status
+func checkForUpdate(server *web.Server, config web.TzSchema, status web.UpdateStatus) web.UpdateStatus {
+	status.LastCheck = time.Now()
+	status.LastError = ""
+
+	latest, err := getLatest(config.ReleasesURL, config.Variant)
+	if err != nil {
+		log.Println("error checking for a new timezone release: ", err)
+		status.LastError = err.Error()
+		return status
+	}
+	status.LatestVersion = latest.Version
+	// releases installed before the variants were recorded are of the default variant
+	current := server.Release()
+	if current.Variant == "" {
+		current.Variant = web.VariantWithOceans
+	}
+	if latest.Version == current.Version && latest.Variant == current.Variant {
+		return status
+	}
+
+	log.Println("updating the timezone database to version", latest.Version)
+	if err = install(latest, config); err != nil {
+		log.Println("error installing the timezone release: ", err)
+		status.LastError = err.Error()
+		return status
+	}
+	// the current database keeps serving if the new one cannot be loaded
+	if _, err = server.Reload(); err != nil {
+		log.Println("error loading the timezone release: ", err)
+		status.LastError = err.Error()
+		return status
+	}
+	status.LastUpdate = time.Now()
+	log.Println("timezone database updated to version", latest.Version)
+	return status
+}
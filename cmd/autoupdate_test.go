@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/noandrea/geo2tz/v2/helpers"
+	"github.com/noandrea/geo2tz/v2/web"
+	"github.com/stretchr/testify/assert"
+)
+
+// testFeaturesUpdate adds a square around Berlin to testFeatures
+const testFeaturesUpdate = testFeatures + "\n" +
+	`{"type":"Feature","properties":{"tzid":"Europe/Berlin"},"geometry":{"type":"Polygon","coordinates":[` +
+	`[[13,52],[14,52],[14,53],[13,53],[13,52]]]}}`
+
+// newTestMirror returns a mirror of the releases, with latest as the latest version;
+// the 2024b release is valid while the 2024c one is not
+func newTestMirror(t *testing.T, latest *atomic.Value, checks *atomic.Int32) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, r *http.Request) {
+		checks.Add(1)
+		http.Redirect(w, r, "/tag/"+latest.Load().(string), http.StatusFound)
+	})
+	mux.HandleFunc("/download/2024b/timezones.geojson.zip", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, testFeaturesUpdate)
+	})
+	mux.HandleFunc("/download/2024c/timezones.geojson.zip", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"type":"FeatureCollection","features":[]}`)
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// newTestServer returns a server with the 2024a release of testFeatures installed
+func newTestServer(t *testing.T, releasesURL string) (*web.Server, web.TzSchema) {
+	dst := t.TempDir()
+	config := web.TzSchema{
+		DatabaseName:       filepath.Join(dst, "timezones.zip"),
+		VersionFile:        filepath.Join(dst, "version.json"),
+		ReleasesURL:        releasesURL,
+		Variant:            web.VariantLand,
+		AutoUpdateInterval: time.Hour,
+	}
+	assert.NoError(t, os.WriteFile(config.DatabaseName, []byte(testFeatures), 0o600))
+	assert.NoError(t, helpers.SaveJSON(web.NewTzReleaseFrom(releasesURL, "2024a", web.VariantLand), config.VersionFile))
+	server, err := web.NewServer(web.ConfigSchema{Tz: config})
+	assert.NoError(t, err)
+	return server, config
+}
+
+func Test_checkForUpdate(t *testing.T) {
+	var latest atomic.Value
+	var checks atomic.Int32
+	ts := newTestMirror(t, &latest, &checks)
+	server, config := newTestServer(t, ts.URL)
+	status := web.UpdateStatus{Interval: config.AutoUpdateInterval.String()}
+
+	// the same version is not installed again
+	latest.Store("2024a")
+	status = checkForUpdate(server, config, status)
+	assert.Empty(t, status.LastError)
+	assert.Equal(t, "2024a", status.LatestVersion)
+	assert.False(t, status.LastCheck.IsZero())
+	assert.True(t, status.LastUpdate.IsZero())
+	data, err := os.ReadFile(config.DatabaseName)
+	assert.NoError(t, err)
+	assert.Equal(t, testFeatures, string(data))
+
+	// a new version is installed and loaded
+	latest.Store("2024b")
+	status = checkForUpdate(server, config, status)
+	assert.Empty(t, status.LastError)
+	assert.Equal(t, "2024b", status.LatestVersion)
+	assert.False(t, status.LastUpdate.IsZero())
+	assert.Equal(t, "2024b", server.Release().Version)
+	assert.Equal(t, web.VariantLand, server.Release().Variant)
+	data, err = os.ReadFile(config.DatabaseName)
+	assert.NoError(t, err)
+	assert.Equal(t, testFeaturesUpdate, string(data))
+
+	// a bad download keeps the data in use
+	lastUpdate := status.LastUpdate
+	latest.Store("2024c")
+	status = checkForUpdate(server, config, status)
+	assert.Contains(t, status.LastError, "invalid timezone data")
+	assert.Equal(t, "2024c", status.LatestVersion)
+	assert.Equal(t, lastUpdate, status.LastUpdate)
+	assert.Equal(t, "2024b", server.Release().Version)
+	data, err = os.ReadFile(config.DatabaseName)
+	assert.NoError(t, err)
+	assert.Equal(t, testFeaturesUpdate, string(data))
+	var current web.TzRelease
+	assert.NoError(t, helpers.LoadJSON(config.VersionFile, &current))
+	assert.Equal(t, "2024b", current.Version)
+
+	assert.EqualValues(t, 3, checks.Load())
+}
+
+func Test_autoUpdate(t *testing.T) {
+	var latest atomic.Value
+	var checks atomic.Int32
+	latest.Store("2024b")
+	ts := newTestMirror(t, &latest, &checks)
+	server, config := newTestServer(t, ts.URL)
+
+	// the first check runs at startup, without waiting for the interval
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		autoUpdate(ctx, server, config)
+	}()
+	assert.Eventually(t, func() bool {
+		return server.Release().Version == "2024b"
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+	assert.EqualValues(t, 1, checks.Load())
+}
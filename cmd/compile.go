@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/noandrea/geo2tz/v2/db"
+	"github.com/noandrea/geo2tz/v2/web"
+	"github.com/spf13/cobra"
+)
+
+// compileCmd represents the compile command
+var compileCmd = &cobra.Command{
+	Use:   "compile OUTPUT",
+	Short: "Compile the timezone data into a binary index for faster startup",
+	Example: `To compile the default database:
+geo2tz compile tzdata/timezones.idx
+
+To use the compiled index when starting the server:
+GEO2TZ_TZ_DATABASE_NAME=tzdata/timezones.idx geo2tz start
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return compile(web.Settings.Tz.DatabaseName, args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(compileCmd)
+	compileCmd.Flags().StringVar(&web.Settings.Tz.DatabaseName, "db", web.TZDBFile, "Source database filename")
+}
+
+// compile writes the binary index of the source database to the target file, the index
+// is written to a temporary file next to it that is renamed into place once complete
+func compile(sourceFile, targetFile string) error {
+	start := time.Now()
+	tzDB, err := db.NewGeo2TzRTreeIndex(sourceFile)
+	if err != nil {
+		return fmt.Errorf("error loading the database %s: %w", sourceFile, err)
+	}
+	fmt.Printf("loaded %s in %v\n", sourceFile, time.Since(start).Round(time.Millisecond))
+
+	tmpFile, _, err := cacheFile(targetFile, tzDB.WriteBinary)
+	if err != nil {
+		return fmt.Errorf("error writing the binary index %s: %w", targetFile, err)
+	}
+	if err = os.Rename(tmpFile, targetFile); err != nil {
+		_ = os.Remove(tmpFile)
+		return err
+	}
+	fmt.Println("binary index written to", targetFile)
+	return nil
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/noandrea/geo2tz/v2/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_compile(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "timezones.json")
+	assert.NoError(t, os.WriteFile(src, []byte(testFeatures), 0o600))
+
+	// the index replaces the target file
+	dst := t.TempDir()
+	target := filepath.Join(dst, "timezones.idx")
+	assert.NoError(t, os.WriteFile(target, []byte("old index"), 0o600))
+	assert.NoError(t, compile(src, target))
+	tzDB, err := db.NewGeo2TzRTreeIndex(target)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, tzDB.Len())
+	entries, _ := os.ReadDir(dst)
+	assert.Len(t, entries, 1)
+
+	// the target file is not touched when the source cannot be loaded
+	assert.Error(t, compile(filepath.Join(dst, "not-found.json"), target))
+	tzDB, err = db.NewGeo2TzRTreeIndex(target)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, tzDB.Len())
+
+	// the target directory must exist
+	assert.Error(t, compile(src, filepath.Join(dst, "missing", "timezones.idx")))
+	entries, _ = os.ReadDir(dst)
+	assert.Len(t, entries, 1)
+}
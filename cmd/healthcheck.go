@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	healthcheckLive    bool
+	healthcheckTimeout time.Duration
+)
+
+// healthcheckCmd represents the healthcheck command
+var healthcheckCmd = &cobra.Command{
+	Use:   "healthcheck",
+	Short: "Check the health of the local geo2tz service",
+	Long: `Probe the readiness endpoint of the service listening on the configured address
+and exit with a non zero status if it is not ready, so that the binary itself can be used
+as a container healthcheck.`,
+	Example: `To check if the service is ready:
+geo2tz healthcheck
+
+To check only if the service is running:
+geo2tz healthcheck --live`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := "/readyz"
+		if healthcheckLive {
+			path = "/healthz"
+		}
+		return healthcheck(settings.Web.ListenAddress, path, healthcheckTimeout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(healthcheckCmd)
+	healthcheckCmd.Flags().BoolVar(&healthcheckLive, "live", false, "Check only if the service is running (liveness), not if it is ready")
+	healthcheckCmd.Flags().DurationVar(&healthcheckTimeout, "timeout", 5*time.Second, "Timeout of the check")
+}
+
+// healthcheck probes the health endpoint at path of the service listening on address
+func healthcheck(address, path string, timeout time.Duration) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %s: %w", address, err)
+	}
+	// the service listening on all the interfaces is reached on the loopback
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(host, port), path)
+
+	client := http.Client{Timeout: timeout}
+	rsp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	defer func() {
+		if err := rsp.Body.Close(); err != nil {
+			fmt.Println("Error closing response body:", err)
+		}
+	}()
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check failed: %s returned %s", url, rsp.Status)
+	}
+	return nil
+}
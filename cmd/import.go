@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/noandrea/geo2tz/v2/web"
+)
+
+// releaseVersionPattern matches the upstream release names (eg. 2023d) in file names
+var releaseVersionPattern = regexp.MustCompile(`(?:^|[^0-9])([0-9]{4}[a-z])(?:[^a-z]|$)`)
+
+// releaseVariantPattern matches the upstream file names (eg. timezones-with-oceans-now.geojson.zip
+// or combined-with-oceans.json), with an optional release name, the variant is empty for land
+var releaseVariantPattern = regexp.MustCompile(`^(?:timezones|combined)(?:-[0-9]{4}[a-z])?(?:-(with-oceans(?:-now|-1970)?|now|1970))?(?:-[0-9]{4}[a-z])?$`)
+
+// importFile installs the timezone data from a local file or directory, if versionName is empty
+// the version is derived from the path; files are installed as they are, in any of the database
+// formats, while the .json and .geojson files of directories are archived
+func importFile(path, versionName string, config web.TzSchema) (err error) {
+	if err = web.ValidateVariant(config.Variant); err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	write := func(w io.Writer) error {
+		return copyFile(path, w)
+	}
+	if info.IsDir() {
+		write = func(w io.Writer) error {
+			return archiveDir(path, w)
+		}
+	}
+	tmpFile, checksum, err := cacheFile(config.DatabaseName, write)
+	if err != nil {
+		return err
+	}
+
+	if versionName == "" {
+		versionName = deriveVersion(path, checksum)
+		fmt.Println("Derived version is", versionName)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		_ = os.Remove(tmpFile)
+		return err
+	}
+	source := (&url.URL{Scheme: "file", Path: filepath.ToSlash(absPath)}).String()
+	release := web.TzRelease{
+		Version:    versionName,
+		URL:        source,
+		GeoDataURL: source,
+		Variant:    config.Variant,
+	}
+	return replace(tmpFile, checksum, release, config)
+}
+
+// copyFile writes the content of the file at path to w
+func copyFile(path string, w io.Writer) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := src.Close(); err != nil {
+			fmt.Println("Error closing file:", err)
+		}
+	}()
+	_, err = io.Copy(w, src)
+	return err
+}
+
+// archiveDir writes to w a zip archive of the .json and .geojson files in the directory at path,
+// that are the entries read by the database loader
+func archiveDir(path string, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	entries := 0
+	err := filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if ext := strings.ToLower(filepath.Ext(name)); ext != ".json" && ext != ".geojson" {
+			return nil
+		}
+		rel, err := filepath.Rel(path, name)
+		if err != nil {
+			return err
+		}
+		entry, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     filepath.ToSlash(rel),
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		entries++
+		return copyFile(name, entry)
+	})
+	if err != nil {
+		return err
+	}
+	if entries == 0 {
+		return fmt.Errorf("no .json or .geojson files found in %s", path)
+	}
+	return zw.Close()
+}
+
+// deriveVersion returns the release name found in the file name, if any,
+// or a local version name built from the data checksum
+func deriveVersion(path, checksum string) string {
+	if m := releaseVersionPattern.FindStringSubmatch(filepath.Base(path)); m != nil {
+		return m[1]
+	}
+	return "local-" + checksum[:12]
+}
+
+// deriveVariant returns the dataset variant of the upstream file names, if the name is one of them
+func deriveVariant(path string) (string, bool) {
+	name, _, _ := strings.Cut(strings.ToLower(filepath.Base(filepath.Clean(path))), ".")
+	m := releaseVariantPattern.FindStringSubmatch(name)
+	if m == nil {
+		return "", false
+	}
+	if m[1] == "" {
+		return web.VariantLand, true
+	}
+	return m[1], true
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/noandrea/geo2tz/v2/db"
+	"github.com/noandrea/geo2tz/v2/helpers"
+	"github.com/noandrea/geo2tz/v2/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_importFile(t *testing.T) {
+	src := t.TempDir()
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, err := zw.Write([]byte(testFeatures))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "timezones-now-2024a.geojson.gz"), gz.Bytes(), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "combined-with-oceans.json"), []byte(testFeatures), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "invalid.json"), []byte(`{"type":"FeatureCollection"}`), 0o600))
+	dir := filepath.Join(src, "timezones")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "nested"), 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "nested", "zones.geojson"), []byte(testFeatures), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not data"), 0o600))
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, "empty"), 0o700))
+
+	tests := []struct {
+		name        string
+		path        string
+		version     string
+		variant     string
+		wantVersion string
+		wantVariant string
+		wantErr     bool
+	}{
+		{"gzip", "timezones-now-2024a.geojson.gz", "", "", "2024a", web.VariantLandNow, false},
+		{"plain geojson", "combined-with-oceans.json", "2023b", "", "2023b", web.VariantWithOceans, false},
+		{"directory", "timezones", "2023c", "", "2023c", web.VariantLand, false},
+		{"given variant", "timezones", "2023c", web.VariantLand1970, "2023c", web.VariantLand1970, false},
+		{"invalid data", "invalid.json", "2023b", "", "", "", true},
+		{"empty directory", "empty", "2023b", "", "", "", true},
+		{"not found", "not-found.json", "2023b", "", "", "", true},
+		{"invalid variant", "combined-with-oceans.json", "2023b", "oceans", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := t.TempDir()
+			config := web.TzSchema{
+				DatabaseName: filepath.Join(dst, "timezones.zip"),
+				VersionFile:  filepath.Join(dst, "version.json"),
+				Variant:      web.VariantWithOceans,
+			}
+			path := filepath.Join(src, tt.path)
+			if variant, ok := deriveVariant(path); ok {
+				config.Variant = variant
+			}
+			if tt.variant != "" {
+				config.Variant = tt.variant
+			}
+			err := importFile(path, tt.version, config)
+			if tt.wantErr {
+				assert.Error(t, err)
+				// the database is not replaced and no temporary files are left
+				entries, _ := os.ReadDir(dst)
+				assert.Empty(t, entries)
+				return
+			}
+			assert.NoError(t, err)
+			tzDB, err := db.NewGeo2TzRTreeIndex(config.DatabaseName)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, tzDB.Len())
+			var release web.TzRelease
+			assert.NoError(t, helpers.LoadJSON(config.VersionFile, &release))
+			assert.Equal(t, tt.wantVersion, release.Version)
+			assert.Equal(t, tt.wantVariant, release.Variant)
+			assert.NotEmpty(t, release.SHA256)
+		})
+	}
+}
+
+func Test_deriveVariant(t *testing.T) {
+	tests := []struct {
+		path   string
+		want   string
+		wantOk bool
+	}{
+		{"timezones-with-oceans.geojson.zip", web.VariantWithOceans, true},
+		{"/tmp/timezones-with-oceans-now.geojson.zip", web.VariantWithOceansNow, true},
+		{"timezones-2023b-with-oceans-1970.geojson.zip", web.VariantWithOceans1970, true},
+		{"timezones.geojson.zip", web.VariantLand, true},
+		{"timezones-2023b.geojson.zip", web.VariantLand, true},
+		{"combined-now.json", web.VariantLandNow, true},
+		{"Combined-1970.JSON", web.VariantLand1970, true},
+		{"timezones/", web.VariantLand, true},
+		{"zones.geojson", "", false},
+		{"timezones-oceans.json", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := deriveVariant(tt.path)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/noandrea/geo2tz/v2/db"
+	"github.com/noandrea/geo2tz/v2/web"
+	"github.com/spf13/cobra"
+)
+
+// exit codes of the lookup command
+const (
+	lookupExitInvalid  = 2 // the coordinates are invalid
+	lookupExitNotFound = 3 // the timezone of the coordinates is not found
+	lookupExitDatabase = 4 // the database cannot be loaded or queried
+)
+
+// output formats of the lookup command
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+var (
+	lookupDB     string
+	lookupFormat string
+)
+
+// lookupCmd represents the lookup command
+var lookupCmd = &cobra.Command{
+	Use:   "lookup LAT LON [LAT LON...]",
+	Short: "Print the timezone of one or more coordinates",
+	Long: `Load the configured database and print the timezone of each pair of coordinates,
+without running the server. In the text format each timezone is printed on its own line,
+in the json format each result is printed as a json object on its own line.
+The coordinates that fail are reported on the standard error and the exit status is
+2 if the coordinates are invalid, 3 if the timezone is not found and 4 if the database
+cannot be loaded, the highest one with several coordinates.
+Negative coordinates must follow a -- separator.`,
+	Example: `To print the timezone of a pair of coordinates:
+geo2tz lookup 41.9028 12.4964
+
+To print the details of several coordinates as json:
+geo2tz lookup --format json -- 41.9028 12.4964 -33.8688 151.2093
+`,
+	Args:          cobra.ArbitraryArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config := settings.Tz
+		if lookupDB != "" {
+			config.DatabaseName = lookupDB
+		}
+		if code := lookup(config, args, lookupFormat, cmd.OutOrStdout(), cmd.ErrOrStderr()); code != 0 {
+			return &ExitError{Code: code}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lookupCmd)
+	lookupCmd.Flags().StringVar(&lookupDB, "db", "", "Database filename, defaults to the configured one")
+	lookupCmd.Flags().StringVar(&lookupFormat, "format", formatText, "Output format: text or json")
+	// the negative coordinates without separator are mistaken for flags
+	lookupCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+		fmt.Fprintln(cmd.ErrOrStderr(), "use -- before the coordinates if they are negative, eg. geo2tz lookup -- -33.8688 151.2093")
+		return &ExitError{Code: lookupExitInvalid}
+	})
+}
+
+// lookupResult is the result of the lookup of a pair of coordinates, as in the api replies
+type lookupResult struct {
+	TzID           string             `json:"tz,omitempty"`
+	Coords         map[string]float64 `json:"coords,omitempty"`
+	Override       bool               `json:"override,omitempty"`
+	Fallback       bool               `json:"fallback,omitempty"`
+	DistanceMeters float64            `json:"distance_meters,omitempty"`
+	Nautical       bool               `json:"nautical,omitempty"`
+	Message        string             `json:"message,omitempty"`
+}
+
+// lookup prints the timezones of the pairs of coordinates in args and returns the exit code
+func lookup(config web.TzSchema, args []string, format string, out, errOut io.Writer) int {
+	if format != formatText && format != formatJSON {
+		fmt.Fprintf(errOut, "Error: invalid format %s, text or json is required\n", format)
+		return lookupExitInvalid
+	}
+	if len(args) == 0 || len(args)%2 != 0 {
+		fmt.Fprintln(errOut, "Error: pairs of coordinates are required, eg. geo2tz lookup 41.9028 12.4964")
+		return lookupExitInvalid
+	}
+	tzDB, err := web.LoadDatabase(config)
+	if err != nil {
+		fmt.Fprintf(errOut, "Error: loading the database %s: %v\n", config.DatabaseName, err)
+		return lookupExitDatabase
+	}
+
+	code := 0
+	enc := json.NewEncoder(out)
+	for i := 0; i < len(args); i += 2 {
+		res, c := lookupCoordinates(tzDB, args[i], args[i+1])
+		// the most severe error sets the exit code
+		code = max(code, c)
+		if res.Message != "" {
+			fmt.Fprintf(errOut, "Error: %s %s: %s\n", args[i], args[i+1], res.Message)
+		}
+		switch {
+		case format == formatJSON:
+			if err := enc.Encode(res); err != nil {
+				fmt.Fprintln(errOut, "Error:", err)
+				return 1
+			}
+		case res.Message == "":
+			fmt.Fprintln(out, res.TzID)
+		}
+	}
+	return code
+}
+
+// lookupCoordinates validates and queries a pair of coordinates as the api does,
+// it returns the result and the exit code
+func lookupCoordinates(tzDB db.TzDBIndex, latValue, lonValue string) (lookupResult, int) {
+	lat, lon, err := web.ParseLatLon(latValue, lonValue)
+	if err != nil {
+		return lookupResult{Message: err.Error()}, lookupExitInvalid
+	}
+	res, err := tzDB.Resolve(lat, lon)
+	if errors.Is(err, db.ErrNotFound) {
+		return lookupResult{Message: fmt.Sprintf("timezone not found for coordinates %f,%f", lat, lon)}, lookupExitNotFound
+	}
+	if err != nil {
+		return lookupResult{Message: err.Error()}, lookupExitDatabase
+	}
+	r := lookupResult{
+		TzID:     res.TzID,
+		Coords:   map[string]float64{web.Latitude: lat, web.Longitude: lon},
+		Override: res.Override,
+		Fallback: res.Fallback,
+		Nautical: res.Nautical,
+	}
+	if res.Fallback {
+		r.DistanceMeters = math.Round(res.Distance*10) / 10
+	}
+	return r, 0
+}
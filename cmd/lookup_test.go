@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/noandrea/geo2tz/v2/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_lookup(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "timezones.json")
+	assert.NoError(t, os.WriteFile(dbFile, []byte(testFeatures), 0o600))
+
+	tests := []struct {
+		name       string
+		dbFile     string
+		args       []string
+		format     string
+		want       string
+		wantErrOut string
+		wantCode   int
+	}{
+		{
+			"text",
+			dbFile,
+			[]string{"41.5", "12.5", "35.5", "139.5"},
+			formatText,
+			"Europe/Rome\nAsia/Tokyo\n",
+			"",
+			0,
+		},
+		{
+			"json",
+			dbFile,
+			[]string{"41.5", "12.5"},
+			formatJSON,
+			`{"tz":"Europe/Rome","coords":{"lat":41.5,"lon":12.5}}` + "\n",
+			"",
+			0,
+		},
+		{
+			"invalid coordinates",
+			dbFile,
+			[]string{"91", "12.5"},
+			formatText,
+			"",
+			"Error: 91 12.5: ",
+			lookupExitInvalid,
+		},
+		{
+			"not found",
+			dbFile,
+			[]string{"0", "0"},
+			formatText,
+			"",
+			"Error: 0 0: timezone not found for coordinates 0.000000,0.000000\n",
+			lookupExitNotFound,
+		},
+		{
+			"mixed pairs, the most severe error sets the exit code",
+			dbFile,
+			[]string{"0", "0", "41.5", "12.5", "lat", "12.5"},
+			formatText,
+			"Europe/Rome\n",
+			"Error: 0 0: timezone not found",
+			lookupExitNotFound,
+		},
+		{
+			"mixed pairs as json",
+			dbFile,
+			[]string{"41.5", "12.5", "0", "0"},
+			formatJSON,
+			`{"tz":"Europe/Rome","coords":{"lat":41.5,"lon":12.5}}` + "\n" +
+				`{"message":"timezone not found for coordinates 0.000000,0.000000"}` + "\n",
+			"Error: 0 0: timezone not found",
+			lookupExitNotFound,
+		},
+		{
+			"odd number of coordinates",
+			dbFile,
+			[]string{"41.5", "12.5", "35.5"},
+			formatText,
+			"",
+			"Error: pairs of coordinates are required",
+			lookupExitInvalid,
+		},
+		{
+			"invalid format",
+			dbFile,
+			[]string{"41.5", "12.5"},
+			"xml",
+			"",
+			"Error: invalid format xml",
+			lookupExitInvalid,
+		},
+		{
+			"database not found",
+			filepath.Join(t.TempDir(), "not-found.zip"),
+			[]string{"41.5", "12.5"},
+			formatText,
+			"",
+			"Error: loading the database",
+			lookupExitDatabase,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			code := lookup(web.TzSchema{DatabaseName: tt.dbFile}, tt.args, tt.format, &out, &errOut)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.want, out.String())
+			if tt.wantErrOut == "" {
+				assert.Empty(t, errOut.String())
+			} else {
+				assert.Contains(t, errOut.String(), tt.wantErrOut)
+			}
+		})
+	}
+}
+
+func Test_lookupFlagError(t *testing.T) {
+	var errOut bytes.Buffer
+	lookupCmd.SetErr(&errOut)
+	defer lookupCmd.SetErr(nil)
+
+	// the negative coordinates are parsed as flags
+	err := lookupCmd.ParseFlags([]string{"-33.8688", "151.2093"})
+	err = lookupCmd.FlagErrorFunc()(lookupCmd, err)
+	var exitErr *ExitError
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, lookupExitInvalid, exitErr.Code)
+	assert.Contains(t, errOut.String(), "use -- before the coordinates")
+}
@@ -21,6 +21,16 @@ type RuntimeVersion struct {
 	BuiltBy string
 }
 
+// ExitError is returned by the commands that exit with a specific status,
+// the errors have already been reported to the user
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
 // rootCmd represents the base command when called without any subcommands.
 var rootCmd = &cobra.Command{
 	Use:   "geo2tz",
@@ -77,7 +87,7 @@ func initConfig() error {
 	// make the version available via settings
 	settings.RuntimeVersion = rootCmd.Version
 	if debug {
-		log.Printf("config %#v", settings)
+		log.Printf("config %#v", settings.Redacted())
 	}
 	return nil
 }
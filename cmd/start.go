@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
@@ -45,14 +47,43 @@ func start(*cobra.Command, []string) {
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server with
-	// a timeout of 10 seconds.
+	// Check for new releases of the timezone data in the background
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if settings.Tz.AutoUpdateInterval > 0 {
+		if err = web.ValidateVariant(settings.Tz.Variant); err != nil {
+			log.Println("Error configuring the background updates ", err)
+			os.Exit(1)
+		}
+		log.Println("background updates enabled, checking every", settings.Tz.AutoUpdateInterval)
+		go autoUpdate(ctx, server, settings.Tz)
+	}
+
+	// Reload the timezone database on SIGHUP
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			log.Println("reloading the timezone database")
+			if release, err := server.Reload(); err != nil {
+				log.Println("error reloading the timezone database: ", err)
+			} else {
+				log.Println("timezone database reloaded, version", release.Version)
+			}
+		}
+	}()
+
+	// Wait for a termination signal to gracefully shutdown the server,
+	// draining the in-flight requests within the shutdown timeout.
 	signalChannelLength := 2
 	quit := make(chan os.Signal, signalChannelLength)
-	signal.Notify(quit, os.Interrupt)
-	<-quit
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
+	sig := <-quit
+	log.Println("received", sig, "shutting down")
+	cancel()
 	if err = server.Teardown(); err != nil {
 		log.Println("error stopping server: ", err)
+		os.Exit(1)
 	}
 	fmt.Print("Goodbye")
 }
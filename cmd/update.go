@@ -16,21 +16,30 @@ limitations under the License.
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/noandrea/geo2tz/v2/db"
 	"github.com/noandrea/geo2tz/v2/helpers"
 	"github.com/noandrea/geo2tz/v2/web"
 	"github.com/spf13/cobra"
 )
 
 const (
-	// geo data url
-	LatestReleaseURL = "https://github.com/evansiroky/timezone-boundary-builder/releases/latest"
+	// LatestReleaseURL is the url of the latest upstream release
+	//
+	// Deprecated: the latest release is looked up relative to the configured releases url.
+	LatestReleaseURL = web.ReleasesURL + latestReleasePath
+	// latestReleasePath is the path of the latest release, relative to the releases url
+	latestReleasePath = "/latest"
 )
 
 // updateCmd represents the build command
@@ -45,76 +54,201 @@ geo2tz update latest
 
 To update to a specific version:
 geo2tz update 2023d
+
+To import the data from a local file, deriving the version from the file:
+geo2tz update --from-file ./timezones-with-oceans.geojson.zip
+
+To import the data from a local file with a specific version:
+geo2tz update 2023d --from-file ./combined-with-oceans.json
 `,
-	Args: cobra.ExactArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		// the version is optional when importing from a file
+		if fromFile != "" {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		config := web.Settings.Tz
+		// the variant and the releases url default to the configured ones
+		if !cmd.Flags().Changed("variant") {
+			config.Variant = settings.Tz.Variant
+		}
+		if !cmd.Flags().Changed("releases-url") {
+			config.ReleasesURL = settings.Tz.ReleasesURL
+		}
+		if fromFile != "" {
+			versionName := ""
+			if len(args) > 0 {
+				versionName = args[0]
+			}
+			// the variant of the upstream files is recorded, unless it is given
+			if !cmd.Flags().Changed("variant") {
+				if variant, ok := deriveVariant(fromFile); ok {
+					config.Variant = variant
+				}
+			}
+			return importFile(fromFile, versionName, config)
+		}
 		versionName := args[0]
-		return update(versionName, web.Settings.Tz.DatabaseName)
+		return update(versionName, config)
 	},
 }
 
+// fromFile is the local file to import the timezone data from
+var fromFile string
+
 func init() {
 	rootCmd.AddCommand(updateCmd)
 	updateCmd.Flags().StringVar(&web.Settings.Tz.DatabaseName, "db", web.TZDBFile, "Destination database filename")
 	updateCmd.Flags().StringVar(&web.Settings.Tz.VersionFile, "version-file", web.TZVersionFile, "Version file")
+	updateCmd.Flags().StringVar(&web.Settings.Tz.ReleasesURL, "releases-url", "", "Base url of the releases, to download from a mirror, defaults to the configured one")
+	updateCmd.Flags().StringVar(&web.Settings.Tz.Variant, "variant", "", "Dataset variant: with-oceans, with-oceans-now, with-oceans-1970, land, now or 1970, defaults to the configured one")
+	updateCmd.Flags().StringVar(&fromFile, "from-file", "", "Import the timezone data from a local file, in any of the database formats, or a directory of .geojson files instead of downloading it")
 }
 
-func update(versionName, targetFile string) (err error) {
-	var release = web.NewTzRelease(versionName)
+func update(versionName string, config web.TzSchema) (err error) {
+	if err = web.ValidateVariant(config.Variant); err != nil {
+		return
+	}
+	var release = web.NewTzReleaseFrom(config.ReleasesURL, versionName, config.Variant)
 	// do we need the latest version?
 	if versionName == "latest" {
-		release, err = getLatest()
+		release, err = getLatest(config.ReleasesURL, config.Variant)
 		if err != nil {
 			return
 		}
 	}
 	// shall we read from the version file?
 	if versionName == "current" {
-		err = helpers.LoadJSON(web.Settings.Tz.VersionFile, &release)
+		err = helpers.LoadJSON(config.VersionFile, &release)
 		if err != nil {
 			return
 		}
 		println("Current version is", release.Version)
 	}
-	if err := fetchAndCacheFile(targetFile, release.GeoDataURL); err != nil {
-		return err
-	}
-	err = helpers.SaveJSON(release, web.Settings.Tz.VersionFile)
-	return
+	return install(release, config)
 }
 
-func fetchAndCacheFile(filename string, url string) (err error) {
-	resp, err := http.Get(url)
+// install downloads the release data, verifies it and replaces the database file,
+// then records the release together with the data checksum in the version file
+func install(release web.TzRelease, config web.TzSchema) (err error) {
+	tmpFile, checksum, err := fetchAndCacheFile(config.DatabaseName, release.GeoDataURL)
 	if err != nil {
 		return err
 	}
-	defer func() { 
-		if err:=resp.Body.Close(); err!=nil{
-			fmt.Println("Error closing response body:", err)
+	return replace(tmpFile, checksum, release, config)
+}
+
+// replace verifies the data in tmpFile and moves it in place of the database file,
+// then records the release together with the data checksum in the version file
+// the version file is written next to it beforehand, so both files are replaced by a rename
+// the temporary files are removed unless they replaced the database and version files
+func replace(tmpFile, checksum string, release web.TzRelease, config web.TzSchema) (err error) {
+	var versionTmpFile string
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmpFile)
+			if versionTmpFile != "" {
+				_ = os.Remove(versionTmpFile)
+			}
 		}
 	}()
+	if err = verify(tmpFile, checksum, release.SHA256); err != nil {
+		return err
+	}
+	release.SHA256 = checksum
+	if versionTmpFile, _, err = cacheFile(config.VersionFile, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(release)
+	}); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpFile, config.DatabaseName); err != nil {
+		return err
+	}
+	return os.Rename(versionTmpFile, config.VersionFile)
+}
 
-	f, err := os.Create(filename)
+// verify checks that the data matches the expected checksum, if any, and that it is a valid database
+func verify(filename, checksum, expectedChecksum string) error {
+	if expectedChecksum != "" && !strings.EqualFold(checksum, expectedChecksum) {
+		return fmt.Errorf("checksum mismatch, expected %s got %s", expectedChecksum, checksum)
+	}
+	// the data is verified as the database loader reads it, whatever the format
+	f, err := os.Open(filename)
 	if err != nil {
 		return err
 	}
+	defer f.Close()
+	tzDB, err := db.NewGeo2TzRTreeIndexFromReader(f)
+	if err != nil {
+		return fmt.Errorf("invalid timezone data: %w", err)
+	}
+	if tzDB.Len() == 0 {
+		return fmt.Errorf("invalid timezone data: no timezones found")
+	}
+	return nil
+}
+
+// fetchAndCacheFile downloads url into a temporary file next to filename,
+// it returns the temporary file name and the SHA-256 checksum of the data
+func fetchAndCacheFile(filename string, url string) (tmpFile, checksum string, err error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", "", err
+	}
 	defer func() {
-		if err := f.Close(); err != nil {
-			fmt.Println("Error closing file:", err)
+		if err := resp.Body.Close(); err != nil {
+			fmt.Println("Error closing response body:", err)
 		}
 	}()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("error downloading %s: %s", url, resp.Status)
+	}
+
+	return cacheFile(filename, func(w io.Writer) error {
+		n, err := io.Copy(w, resp.Body)
+		if err != nil {
+			return err
+		}
+		if resp.ContentLength >= 0 && n != resp.ContentLength {
+			return fmt.Errorf("incomplete download, expected %d bytes got %d", resp.ContentLength, n)
+		}
+		return nil
+	})
+}
 
-	n, err := io.Copy(f, resp.Body)
+// cacheFile writes the data produced by write into a temporary file next to filename,
+// so it can be renamed into place, it returns the temporary file name and the
+// SHA-256 checksum of its content
+func cacheFile(filename string, write func(w io.Writer) error) (tmpFile, checksum string, err error) {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.download")
 	if err != nil {
-		return err
+		return "", "", err
+	}
+	name := f.Name()
+	defer func() {
+		if cErr := f.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+		if err != nil {
+			_ = os.Remove(name)
+		}
+	}()
+	if err = f.Chmod(0o644); err != nil {
+		return "", "", err
 	}
-	if n != resp.ContentLength {
-		fmt.Println(n, resp.ContentLength)
+
+	h := sha256.New()
+	if err = write(io.MultiWriter(f, h)); err != nil {
+		return "", "", err
 	}
-	return
+	return name, hex.EncodeToString(h.Sum(nil)), nil
 }
 
-func getLatest() (web.TzRelease, error) {
+func getLatest(releasesURL, variant string) (web.TzRelease, error) {
 	// create http client
 	client := &http.Client{
 		Timeout: 1 * time.Second,
@@ -123,7 +257,7 @@ func getLatest() (web.TzRelease, error) {
 			return http.ErrUseLastResponse
 		},
 	}
-	r, err := client.Head(LatestReleaseURL)
+	r, err := client.Head(strings.TrimSuffix(releasesURL, "/") + latestReleasePath)
 	if err != nil {
 		err = fmt.Errorf("failed to get release url: %w", err)
 		return web.TzRelease{}, err
@@ -139,6 +273,6 @@ func getLatest() (web.TzRelease, error) {
 		err = fmt.Errorf("failed to get release url: %w", err)
 		return web.TzRelease{}, err
 	}
-	v := web.NewTzRelease(releaseURL.Path[strings.LastIndex(releaseURL.Path, "/")+1:])
+	v := web.NewTzReleaseFrom(releasesURL, releaseURL.Path[strings.LastIndex(releaseURL.Path, "/")+1:], variant)
 	return v, nil
 }
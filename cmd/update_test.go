@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/noandrea/geo2tz/v2/helpers"
+	"github.com/noandrea/geo2tz/v2/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_install(t *testing.T) {
+	sum := sha256.Sum256([]byte(testFeatures))
+	checksum := hex.EncodeToString(sum[:])
+	mux := http.NewServeMux()
+	mux.HandleFunc("/timezones.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, testFeatures)
+	})
+	mux.HandleFunc("/truncated.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(testFeatures)))
+		_, _ = io.WriteString(w, testFeatures[:len(testFeatures)/2])
+	})
+	mux.HandleFunc("/invalid.zip", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "PK\x03\x04not a zip archive")
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		checksum string
+		wantErr  string
+	}{
+		{"PASS: valid data", "/timezones.json", "", ""},
+		{"PASS: matching checksum", "/timezones.json", checksum, ""},
+		{"FAIL: checksum mismatch", "/timezones.json", "00" + checksum[2:], "checksum mismatch"},
+		{"FAIL: truncated download", "/truncated.json", "", "unexpected EOF"},
+		{"FAIL: invalid zip", "/invalid.zip", "", "invalid timezone data"},
+		{"FAIL: not found", "/not-found.json", "", "404 Not Found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := t.TempDir()
+			config := web.TzSchema{
+				DatabaseName: filepath.Join(dst, "timezones.zip"),
+				VersionFile:  filepath.Join(dst, "version.json"),
+			}
+			assert.NoError(t, os.WriteFile(config.DatabaseName, []byte("old data"), 0o600))
+			assert.NoError(t, helpers.SaveJSON(web.TzRelease{Version: "2023a"}, config.VersionFile))
+
+			release := web.TzRelease{Version: "2024a", GeoDataURL: ts.URL + tt.path, SHA256: tt.checksum}
+			err := install(release, config)
+
+			entries, _ := os.ReadDir(dst)
+			// no temporary files are left
+			assert.Len(t, entries, 2)
+			var current web.TzRelease
+			assert.NoError(t, helpers.LoadJSON(config.VersionFile, &current))
+			data, _ := os.ReadFile(config.DatabaseName)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				// the database and the version file are not replaced
+				assert.Equal(t, "old data", string(data))
+				assert.Equal(t, "2023a", current.Version)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testFeatures, string(data))
+			assert.Equal(t, "2024a", current.Version)
+			assert.Equal(t, checksum, current.SHA256)
+		})
+	}
+}
+
+func Test_cacheFile(t *testing.T) {
+	dst := t.TempDir()
+	filename := filepath.Join(dst, "timezones.zip")
+
+	tmpFile, checksum, err := cacheFile(filename, func(w io.Writer) error {
+		_, err := io.WriteString(w, "data")
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, dst, filepath.Dir(tmpFile))
+	assert.Equal(t, "3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7", checksum)
+	data, err := os.ReadFile(tmpFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+	assert.NoError(t, os.Remove(tmpFile))
+
+	// the temporary file is removed when the data cannot be written
+	_, _, err = cacheFile(filename, func(w io.Writer) error {
+		_, _ = io.WriteString(w, "partial")
+		return errors.New("write failed")
+	})
+	assert.EqualError(t, err, "write failed")
+	entries, _ := os.ReadDir(dst)
+	assert.Empty(t, entries)
+}
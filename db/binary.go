@@ -0,0 +1,185 @@
+package db
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+/*
+Binary index format
+
+The binary format is a compact serialization of the index that can be loaded
+without parsing the GeoJSON source. All the numbers are little endian.
+
+	header   magic (8 bytes) | zones count (uint32)
+	zone     name length (uint16) | name | polygons count (uint32) | polygons
+	polygon  ring | holes count (uint32) | rings
+	ring     vertices count (uint32) | min lat | min lng | max lat | max lng | vertices (lat, lng)
+*/
+
+// BinaryMagic is the header that identifies a binary index file
+const BinaryMagic = "G2TZIDX1"
+
+var (
+	// ErrInvalidBinary is returned when a binary index file is malformed
+	ErrInvalidBinary = errors.New("invalid binary index")
+)
+
+// decodeBinary decodes the zones of a binary index as they are read, without loading the whole
+// index in memory
+func decodeBinary(rd io.Reader, iter func(tz *timezoneGeo) error) error {
+	r := &binaryReader{r: bufio.NewReader(rd)}
+	if string(r.next(len(BinaryMagic))) != BinaryMagic {
+		return fmt.Errorf("%w: missing header", ErrInvalidBinary)
+	}
+	zones := r.uint32()
+	for i := uint32(0); i < zones && r.err == nil; i++ {
+		tz := timezoneGeo{Name: r.string()}
+		polygons := r.uint32()
+		for j := uint32(0); j < polygons && r.err == nil; j++ {
+			p := r.ring()
+			holes := r.uint32()
+			for k := uint32(0); k < holes && r.err == nil; k++ {
+				p.Holes = append(p.Holes, r.ring())
+			}
+			tz.Polygons = append(tz.Polygons, p)
+		}
+		if r.err != nil {
+			break
+		}
+		if err := iter(&tz); err != nil {
+			return err
+		}
+	}
+	if r.err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBinary, r.err)
+	}
+	return nil
+}
+
+// WriteBinary writes the index in the binary format
+func (g *Geo2TzRTreeIndex) WriteBinary(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	buf := []byte(BinaryMagic)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(g.zones)))
+	for _, tz := range g.zones {
+		if len(tz.Name) > math.MaxUint16 {
+			return fmt.Errorf("timezone name too long: %s", tz.Name)
+		}
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(len(tz.Name)))
+		buf = append(buf, tz.Name...)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(tz.Polygons)))
+		for _, p := range tz.Polygons {
+			buf = appendRing(buf, p)
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(len(p.Holes)))
+			for _, h := range p.Holes {
+				buf = appendRing(buf, h)
+			}
+			// flush the buffer once per polygon to keep the memory usage low
+			if _, err := bw.Write(buf); err != nil {
+				return err
+			}
+			buf = buf[:0]
+		}
+	}
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// appendRing appends the bounding box and the vertices of a polygon ring to buf
+func appendRing(buf []byte, p polygon) []byte {
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(p.Vertices)))
+	for _, v := range []float64{p.MinLat, p.MinLng, p.MaxLat, p.MaxLng} {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+	}
+	for _, v := range p.Vertices {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v.lat))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v.lng))
+	}
+	return buf
+}
+
+// binaryReader reads values from a binary index, the first error is recorded
+// and all the following reads return zero values
+type binaryReader struct {
+	r   *bufio.Reader
+	buf [16]byte
+	err error
+}
+
+// next reads n bytes, up to the size of the buffer, the bytes are valid until the next read
+func (r *binaryReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if _, err := io.ReadFull(r.r, r.buf[:n]); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		r.err = err
+		return nil
+	}
+	return r.buf[:n]
+}
+
+func (r *binaryReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *binaryReader) float64() float64 {
+	if b := r.next(8); b != nil {
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (r *binaryReader) string() string {
+	b := r.next(2)
+	if b == nil {
+		return ""
+	}
+	name := make([]byte, binary.LittleEndian.Uint16(b))
+	if _, err := io.ReadFull(r.r, name); err != nil {
+		r.err = io.ErrUnexpectedEOF
+		return ""
+	}
+	return string(name)
+}
+
+// ringPrealloc caps the vertices allocated upfront, so corrupted counts fail
+// on the end of the data instead of allocating for them
+const ringPrealloc = 4096
+
+func (r *binaryReader) ring() polygon {
+	n := r.uint32()
+	p := polygon{
+		MinLat: r.float64(),
+		MinLng: r.float64(),
+		MaxLat: r.float64(),
+		MaxLng: r.float64(),
+	}
+	if r.err != nil {
+		return p
+	}
+	p.Vertices = make([]vertex, 0, min(n, ringPrealloc))
+	for i := uint32(0); i < n; i++ {
+		b := r.next(16)
+		if b == nil {
+			return p
+		}
+		p.Vertices = append(p.Vertices, vertex{
+			lat: math.Float64frombits(binary.LittleEndian.Uint64(b)),
+			lng: math.Float64frombits(binary.LittleEndian.Uint64(b[8:])),
+		})
+	}
+	return p
+}
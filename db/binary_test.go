@@ -0,0 +1,68 @@
+package db
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeo2TzRTreeIndex_WriteBinary(t *testing.T) {
+	gsi, err := NewGeo2TzRTreeIndex("testdata/timezones.zip")
+	assert.NoError(t, err)
+
+	// compile the index
+	idxPath := filepath.Join(t.TempDir(), "timezones.idx")
+	f, err := os.Create(idxPath)
+	assert.NoError(t, err)
+	assert.NoError(t, gsi.WriteBinary(f))
+	assert.NoError(t, f.Close())
+
+	// load it back, format detection must pick the binary loader
+	bsi, err := NewGeo2TzRTreeIndex(idxPath)
+	assert.NoError(t, err)
+	assert.Equal(t, gsi.zones, bsi.zones)
+	assert.Equal(t, gsi.land.Len(), bsi.land.Len())
+	assert.Equal(t, gsi.sea.Len(), bsi.sea.Len())
+
+	coords := []struct {
+		lat, lon float64
+	}{
+		{41.9028, 12.4964},  // Rome
+		{41.9029, 12.4534},  // Vatican City, hole in Europe/Rome
+		{52.52, 13.405},     // Berlin
+		{35.6762, 139.6503}, // Tokyo
+		{40.7128, -74.006},  // New York
+		{0, 0},
+	}
+	for _, c := range coords {
+		want, wantErr := gsi.Lookup(c.lat, c.lon)
+		got, gotErr := bsi.Lookup(c.lat, c.lon)
+		assert.Equal(t, wantErr, gotErr)
+		assert.Equal(t, want, got)
+	}
+}
+
+func Test_decodeBinary(t *testing.T) {
+	noop := func(tz *timezoneGeo) error { return nil }
+
+	err := decodeBinary(strings.NewReader("not an index"), noop)
+	assert.ErrorIs(t, err, ErrInvalidBinary)
+
+	// one zone with a polygon declaring more vertices than available
+	data := []byte(BinaryMagic)
+	data = append(data, 1, 0, 0, 0, 3, 0, 'U', 'T', 'C', 1, 0, 0, 0, 0xff, 0xff, 0xff, 0xff)
+	err = decodeBinary(bytes.NewReader(data), noop)
+	assert.ErrorIs(t, err, ErrInvalidBinary)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// a truncated index file is detected as binary
+	truncated := filepath.Join(t.TempDir(), "truncated.idx")
+	assert.NoError(t, os.WriteFile(truncated, data, 0o600))
+	_, err = NewGeo2TzRTreeIndex(truncated)
+	assert.ErrorIs(t, err, ErrInvalidBinary)
+}
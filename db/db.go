@@ -4,6 +4,38 @@ import "errors"
 
 type TzDBIndex interface {
 	Lookup(lat, lon float64) (string, error)
+	Resolve(lat, lon float64) (Result, error)
+	LookupAll(lat, lon float64) ([]string, error)
+}
+
+// Result holds the timezone found for a pair of coordinates and how it was found
+type Result struct {
+	TzID string
+	// Override is true when the coordinates are in one of the override zones
+	Override bool
+	// Fallback is true when the coordinates are outside all the polygons and the closest zone is returned
+	Fallback bool
+	// Distance is the distance in meters from the closest polygon edge, only set for fallback results
+	Distance float64
+	// Nautical is true when the coordinates are outside all the polygons and the zone is computed from the longitude
+	Nautical bool
+}
+
+// the sources of a lookup result
+const (
+	SourceOverride = "override"
+	SourceLand     = "land"
+	SourceSea      = "sea"
+	SourceFallback = "fallback"
+	SourceNautical = "nautical"
+)
+
+// LookupStats describes how a lookup was resolved
+type LookupStats struct {
+	// Source is the index that provided the result, empty when the timezone is not found
+	Source string
+	// Tested is the number of polygons tested for containment
+	Tested int
 }
 
 var (
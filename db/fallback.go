@@ -0,0 +1,118 @@
+package db
+
+import (
+	"math"
+
+	"github.com/tidwall/rtree"
+)
+
+const (
+	// earthRadius is the mean earth radius in meters
+	earthRadius = 6371008.8
+	// metersPerDegree is the length in meters of a degree of latitude
+	metersPerDegree = earthRadius * math.Pi / 180
+)
+
+// closest returns the timezone ID of the polygon with the edge closest to the given
+// coordinates and its distance in meters, considering only polygons within maxDistance
+// it returns an empty string if there are none
+func (g *Geo2TzRTreeIndex) closest(lat, lng, maxDistance float64) (tzID string, distance float64) {
+	// the search window, the longitude span grows toward the poles
+	dLat := maxDistance / metersPerDegree
+	dLng := 180.0
+	if cos := math.Cos(lat * math.Pi / 180); cos > 0 {
+		dLng = min(dLat/cos, 180)
+	}
+	// the window is split in two when it crosses the antimeridian
+	windows := [][2][2]float64{{{lat - dLat, lng - dLng}, {lat + dLat, lng + dLng}}}
+	if lng-dLng < -180 {
+		windows[0][0][1] = -180
+		windows = append(windows, [2][2]float64{{lat - dLat, lng - dLng + 360}, {lat + dLat, 180}})
+	}
+	if lng+dLng > 180 {
+		windows[0][1][1] = 180
+		windows = append(windows, [2][2]float64{{lat - dLat, -180}, {lat + dLat, lng + dLng - 360}})
+	}
+
+	distance = maxDistance
+	visited := map[string]bool{}
+	search := func(tree *rtree.RTreeG[timezoneGeo], window [2][2]float64) {
+		tree.Search(window[0], window[1], func(min, max [2]float64, data timezoneGeo) bool {
+			// each polygon is indexed separately but the data holds all the zone polygons
+			if visited[data.Name] {
+				return true
+			}
+			visited[data.Name] = true
+			for _, p := range data.Polygons {
+				if d := distanceToPolygon(vertex{lat, lng}, p, distance); d < distance {
+					tzID, distance = data.Name, d
+				}
+			}
+			return true
+		})
+	}
+	for _, window := range windows {
+		search(&g.land, window)
+		search(&g.sea, window)
+	}
+	if tzID == "" {
+		return "", 0
+	}
+	return tzID, distance
+}
+
+// distanceToPolygon returns the distance in meters between a point and the closest edge
+// of the polygon, including the edges of its holes
+// polygons with a bounding box farther than limit are skipped and limit is returned
+func distanceToPolygon(point vertex, p polygon, limit float64) float64 {
+	if distanceToBox(point, p) > limit {
+		return limit
+	}
+	d := distanceToRing(point, p.Vertices, limit)
+	for _, h := range p.Holes {
+		d = min(d, distanceToPolygon(point, h, d))
+	}
+	return d
+}
+
+// distanceToBox returns an approximation of the distance in meters between a point
+// and the bounding box of a polygon, that is never greater than the real distance
+func distanceToBox(point vertex, p polygon) float64 {
+	dLat := max(p.MinLat-point.lat, point.lat-p.MaxLat, 0)
+	return dLat * metersPerDegree
+}
+
+// distanceToRing returns the distance in meters between a point and the closest edge of a ring
+func distanceToRing(point vertex, vertices []vertex, limit float64) float64 {
+	// project the vertices on a plane tangent to the point, that is accurate
+	// enough for the short distances used by the fallback
+	cos := math.Cos(point.lat * math.Pi / 180)
+	project := func(v vertex) (x, y float64) {
+		dLng := v.lng - point.lng
+		if dLng > 180 {
+			dLng -= 360
+		} else if dLng < -180 {
+			dLng += 360
+		}
+		return dLng * cos * metersPerDegree, (v.lat - point.lat) * metersPerDegree
+	}
+
+	d := limit
+	n := len(vertices)
+	for i := 0; i < n; i++ {
+		ax, ay := project(vertices[i])
+		bx, by := project(vertices[(i+1)%n])
+		d = min(d, distanceToSegment(ax, ay, bx, by))
+	}
+	return d
+}
+
+// distanceToSegment returns the distance between the origin and the segment a-b
+func distanceToSegment(ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/l))
+	}
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
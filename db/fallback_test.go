@@ -0,0 +1,93 @@
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeo2TzRTreeIndex_ResolveFallback(t *testing.T) {
+	gsi, err := NewGeo2TzRTreeIndex("testdata/timezones.zip")
+	assert.NoError(t, err)
+
+	// Vatican City is a hole in Europe/Rome, and the test dataset has no zone for it
+	vaticanLat, vaticanLon := 41.9029, 12.4534
+
+	// without fallback the point is not found
+	_, err = gsi.Resolve(vaticanLat, vaticanLon)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// the closest zone is within a kilometer
+	gsi.SetFallbackMaxDistance(1000)
+	got, err := gsi.Resolve(vaticanLat, vaticanLon)
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Rome", got.TzID)
+	assert.True(t, got.Fallback)
+	assert.Greater(t, got.Distance, 0.0)
+	assert.Less(t, got.Distance, 1000.0)
+
+	// points inside a polygon are not fallback results
+	got, err = gsi.Resolve(41.9028, 12.4964)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{TzID: "Europe/Rome"}, got)
+
+	// the closest zone is too far away
+	gsi.SetFallbackMaxDistance(10)
+	_, err = gsi.Resolve(vaticanLat, vaticanLon)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGeo2TzRTreeIndex_ResolveFallbackAntimeridian(t *testing.T) {
+	// a zone on each side of the antimeridian
+	gsi, err := NewGeo2TzRTreeIndexFromReader(strings.NewReader(
+		`{"type":"Feature","properties":{"tzid":"Pacific/Fiji"},"geometry":{"type":"Polygon","coordinates":[` +
+			`[[179.5,-17],[179.9,-17],[179.9,-16],[179.5,-16],[179.5,-17]]]}}` + "\n" +
+			`{"type":"Feature","properties":{"tzid":"Pacific/Tongatapu"},"geometry":{"type":"Polygon","coordinates":[` +
+			`[[-179.9,-21],[-179.5,-21],[-179.5,-20],[-179.9,-20],[-179.9,-21]]]}}`))
+	assert.NoError(t, err)
+	gsi.SetFallbackMaxDistance(20_000)
+
+	tests := []struct {
+		name     string
+		lat, lng float64
+		want     string
+	}{
+		{"west of the zone across the antimeridian", -16.5, -179.95, "Pacific/Fiji"},
+		{"east of the zone across the antimeridian", -20.5, 179.95, "Pacific/Tongatapu"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gsi.Resolve(tt.lat, tt.lng)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.TzID)
+			assert.True(t, got.Fallback)
+			assert.Less(t, got.Distance, 20_000.0)
+		})
+	}
+}
+
+func Test_distanceToRing(t *testing.T) {
+	// a square of 0.2 degrees around the equator
+	p := newPolygon()
+	for _, v := range []vertex{{-0.1, -0.1}, {-0.1, 0.1}, {0.1, 0.1}, {0.1, -0.1}, {-0.1, -0.1}} {
+		p.AddVertex(v.lat, v.lng)
+	}
+
+	tests := []struct {
+		name  string
+		point vertex
+		want  float64
+	}{
+		{"on the edge", vertex{0, 0.1}, 0},
+		{"east of the edge", vertex{0, 0.2}, 0.1 * metersPerDegree},
+		{"north of the edge", vertex{0.15, 0}, 0.05 * metersPerDegree},
+		{"from the center", vertex{0, 0}, 0.1 * metersPerDegree},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := distanceToRing(tt.point, p.Vertices, 100_000)
+			assert.InDelta(t, tt.want, got, tt.want*0.001+0.01)
+		})
+	}
+}
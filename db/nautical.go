@@ -0,0 +1,29 @@
+package db
+
+import (
+	"fmt"
+	"math"
+)
+
+// NauticalTimezone returns the nautical timezone for a longitude: the zones are 15° wide and
+// centered on the multiples of 15°, with the 12th zone split in two by the antimeridian.
+// IANA names the zones with the sign inverted, so that Etc/GMT-1 is UTC+1.
+func NauticalTimezone(lng float64) string {
+	offset := int(math.Round(lng / 15))
+	offset = max(min(offset, 12), -12)
+	switch {
+	case offset > 0:
+		return fmt.Sprintf("Etc/GMT-%d", offset)
+	case offset < 0:
+		return fmt.Sprintf("Etc/GMT+%d", -offset)
+	default:
+		return "Etc/GMT"
+	}
+}
+
+// SetNauticalFallback enables the nautical timezone, computed from the longitude,
+// for points that are not found in any polygon, so that datasets without oceans
+// can still resolve points at sea
+func (g *Geo2TzRTreeIndex) SetNauticalFallback(enabled bool) {
+	g.nauticalFallback = enabled
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNauticalTimezone(t *testing.T) {
+	tests := []struct {
+		lng  float64
+		want string
+	}{
+		{0, "Etc/GMT"},
+		{7.4, "Etc/GMT"},
+		{-7.4, "Etc/GMT"},
+		{7.6, "Etc/GMT-1"},
+		{-7.6, "Etc/GMT+1"},
+		{45, "Etc/GMT-3"},
+		{-75, "Etc/GMT+5"},
+		{172.4, "Etc/GMT-11"},
+		{172.6, "Etc/GMT-12"},
+		{180, "Etc/GMT-12"},
+		{-172.6, "Etc/GMT+12"},
+		{-180, "Etc/GMT+12"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.lng), func(t *testing.T) {
+			assert.Equal(t, tt.want, NauticalTimezone(tt.lng))
+		})
+	}
+}
+
+func TestGeo2TzRTreeIndex_ResolveNautical(t *testing.T) {
+	// the test dataset has no ocean zones
+	gsi, err := NewGeo2TzRTreeIndex("testdata/timezones.zip")
+	assert.NoError(t, err)
+	assert.False(t, gsi.HasOceans())
+
+	// a point in the Atlantic ocean
+	_, err = gsi.Resolve(30, -40)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	gsi.SetNauticalFallback(true)
+	got, err := gsi.Resolve(30, -40)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{TzID: "Etc/GMT+3", Nautical: true}, got)
+
+	// land zones take precedence
+	got, err = gsi.Resolve(41.9028, 12.4964)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{TzID: "Europe/Rome"}, got)
+
+	// and so does the closest zone fallback, Vatican City is a hole in Europe/Rome
+	gsi.SetFallbackMaxDistance(1000)
+	got, err = gsi.Resolve(41.9029, 12.4534)
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Rome", got.TzID)
+	assert.True(t, got.Fallback)
+	assert.False(t, got.Nautical)
+}
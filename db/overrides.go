@@ -0,0 +1,55 @@
+package db
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tidwall/rtree"
+)
+
+// LoadOverrides loads the override zones from a reader, in any of the formats accepted by
+// NewGeo2TzRTreeIndexFromReader, replacing the ones previously loaded.
+// The override zones take precedence over the dataset zones, so that custom boundaries
+// can be layered on top of the upstream data. Each zone must be a valid IANA timezone.
+// It is not safe to call on an index that is serving lookups, the reloads load the
+// overrides on a new index before replacing the one in use
+func (g *Geo2TzRTreeIndex) LoadOverrides(r io.Reader) error {
+	var overrides rtree.RTreeG[timezoneGeo]
+	if err := decodeData(r, func(tz *timezoneGeo) error {
+		if tz.Name == "" {
+			return ErrInternal
+		}
+		if _, err := time.LoadLocation(tz.Name); err != nil {
+			return fmt.Errorf("invalid override timezone %q: %w", tz.Name, err)
+		}
+		for _, p := range tz.Polygons {
+			overrides.Insert([2]float64{p.MinLat, p.MinLng}, [2]float64{p.MaxLat, p.MaxLng}, *tz)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	g.overrides = overrides
+	return nil
+}
+
+// LoadOverridesFile loads the override zones from a file
+func (g *Geo2TzRTreeIndex) LoadOverridesFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			fmt.Println("Error closing overrides file:", err)
+		}
+	}()
+	return g.LoadOverrides(f)
+}
+
+// HasOverrides checks if the index has override zones
+func (g *Geo2TzRTreeIndex) HasOverrides() bool {
+	return g.overrides.Len() > 0
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// a site in Berlin that follows the time of Tokyo
+const featureOverride = `{"type":"Feature","properties":{"tzid":"Asia/Tokyo"},"geometry":{"type":"Polygon","coordinates":[` +
+	`[[13.3,52.4],[13.5,52.4],[13.5,52.6],[13.3,52.6],[13.3,52.4]]]}}`
+
+func TestGeo2TzRTreeIndex_LoadOverrides(t *testing.T) {
+	gsi, err := NewGeo2TzRTreeIndex("testdata/timezones.zip")
+	assert.NoError(t, err)
+	assert.False(t, gsi.HasOverrides())
+
+	got, err := gsi.Resolve(52.52, 13.405)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{TzID: "Europe/Berlin"}, got)
+
+	assert.NoError(t, gsi.LoadOverrides(strings.NewReader(featureOverride)))
+	assert.True(t, gsi.HasOverrides())
+
+	// the override takes precedence over the dataset zones
+	got, err = gsi.Resolve(52.52, 13.405)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{TzID: "Asia/Tokyo", Override: true}, got)
+	tzID, err := gsi.Lookup(52.52, 13.405)
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Tokyo", tzID)
+	zones, err := gsi.LookupAll(52.52, 13.405)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Asia/Tokyo", "Europe/Berlin"}, zones)
+
+	// outside the override the dataset is used
+	got, err = gsi.Resolve(52.3, 13.405)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{TzID: "Europe/Berlin"}, got)
+
+	// overrides can cover points outside all the dataset zones
+	assert.NoError(t, gsi.LoadOverrides(strings.NewReader(featureOverride+"\n"+
+		`{"type":"Feature","properties":{"tzid":"Etc/GMT+3"},"geometry":{"type":"Polygon","coordinates":[[[-41,29],[-39,29],[-39,31],[-41,31],[-41,29]]]}}`)))
+	got, err = gsi.Resolve(30, -40)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{TzID: "Etc/GMT+3", Override: true}, got)
+
+	// loading the overrides replaces the previous ones
+	assert.NoError(t, gsi.LoadOverrides(strings.NewReader(`{"type":"FeatureCollection","features":[]}`)))
+	assert.False(t, gsi.HasOverrides())
+	got, err = gsi.Resolve(52.52, 13.405)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{TzID: "Europe/Berlin"}, got)
+
+	// invalid overrides keep the previous ones
+	assert.NoError(t, gsi.LoadOverrides(strings.NewReader(featureOverride)))
+	err = gsi.LoadOverrides(strings.NewReader(`{"type":"Feature","properties":{},"geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}}`))
+	assert.ErrorIs(t, err, ErrInternal)
+	assert.True(t, gsi.HasOverrides())
+	err = gsi.LoadOverrides(strings.NewReader(strings.Replace(featureOverride, "Asia/Tokyo", "Asia/Atlantis", 1)))
+	assert.ErrorContains(t, err, `invalid override timezone "Asia/Atlantis"`)
+	assert.True(t, gsi.HasOverrides())
+}
+
+func TestGeo2TzRTreeIndex_LoadOverridesFile(t *testing.T) {
+	gsi := &Geo2TzRTreeIndex{max_lookups: defaultMaxLookups}
+
+	err := gsi.LoadOverridesFile(filepath.Join(t.TempDir(), "not_found.geojson"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	path := filepath.Join(t.TempDir(), "overrides.geojson")
+	assert.NoError(t, os.WriteFile(path, []byte(featureOverride), 0o600))
+	assert.NoError(t, gsi.LoadOverridesFile(path))
+	got, err := gsi.Resolve(52.52, 13.405)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{TzID: "Asia/Tokyo", Override: true}, got)
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	// zipMagic is the header of a zip archive
+	zipMagic = []byte("PK\x03\x04")
+	// gzipMagic is the header of a gzip stream
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// NewGeo2TzRTreeIndexFromReader creates a new Geo2TzRTreeIndex from a reader,
+// the format is detected from the content and can be a binary index, a zip archive
+// of GeoJSON files, a gzip compressed stream or plain GeoJSON: a feature collection,
+// a single feature, newline delimited features or a GeoJSON text sequence
+func NewGeo2TzRTreeIndexFromReader(r io.Reader) (*Geo2TzRTreeIndex, error) {
+	gri := &Geo2TzRTreeIndex{
+		max_lookups: defaultMaxLookups,
+	}
+	if err := decodeData(r, func(tz *timezoneGeo) error {
+		gri.add(*tz)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return gri, nil
+}
+
+// decodeData detects the format of the data and decodes its zones
+func decodeData(r io.Reader, iter func(tz *timezoneGeo) error) error {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(len(BinaryMagic))
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	switch {
+	case bytes.HasPrefix(header, []byte(BinaryMagic)):
+		return decodeBinary(br, iter)
+	case bytes.HasPrefix(header, zipMagic):
+		return decodeZip(r, br, iter)
+	case bytes.HasPrefix(header, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := gz.Close(); err != nil {
+				fmt.Println("Error closing gzip reader:", err)
+			}
+		}()
+		return decodeData(gz, iter)
+	default:
+		return decodeStream(br, iter)
+	}
+}
+
+// decodeZip decodes the .json and .geojson entries of a zip archive
+func decodeZip(r io.Reader, br *bufio.Reader, iter func(tz *timezoneGeo) error) error {
+	ra, size, err := readerAt(r, br)
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return err
+	}
+	for _, v := range zr.File {
+		name := strings.ToLower(v.Name)
+		if !strings.HasSuffix(name, ".json") && !strings.HasSuffix(name, ".geojson") {
+			continue
+		}
+		if err := decodeJSON(v, iter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readerAt returns a random access reader for the data, regular files are read in place
+// from their current offset while other readers are loaded in memory
+func readerAt(r io.Reader, br *bufio.Reader) (io.ReaderAt, int64, error) {
+	if f, ok := r.(*os.File); ok {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, 0, err
+		}
+		// pipes cannot be read in place
+		if offset, err := f.Seek(0, io.SeekCurrent); err == nil && info.Mode().IsRegular() {
+			offset -= int64(br.Buffered())
+			return io.NewSectionReader(f, offset, info.Size()-offset), info.Size() - offset, nil
+		}
+	}
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// a square around Rome with a hole around the Vatican
+	featureRome = `{"type":"Feature","properties":{"tzid":"Europe/Rome"},"geometry":{"type":"Polygon","coordinates":[` +
+		`[[12,41],[13,41],[13,42],[12,42],[12,41]],` +
+		`[[12.4,41.8],[12.5,41.8],[12.5,42.0],[12.4,42.0],[12.4,41.8]]]}}`
+	// a square around the Vatican
+	featureVatican = `{"type":"Feature","properties":{"tzid":"Europe/Vatican"},"geometry":{"type":"Polygon","coordinates":[` +
+		`[[12.4,41.8],[12.5,41.8],[12.5,42.0],[12.4,42.0],[12.4,41.8]]]}}`
+	// two squares around Tokyo and Osaka
+	featureTokyo = `{"type":"Feature","properties":{"tzid":"Asia/Tokyo"},"geometry":{"type":"MultiPolygon","coordinates":[` +
+		`[[[139,35],[140,35],[140,36],[139,36],[139,35]]],` +
+		`[[[135,34],[136,34],[136,35],[135,35],[135,34]]]]}}`
+)
+
+func TestNewGeo2TzRTreeIndexFromReader(t *testing.T) {
+	collection := `{"type":"FeatureCollection","name":"timezones","crs":{"type":"name"},"features":[` +
+		featureRome + "," + featureVatican + "," + featureTokyo + "]}"
+
+	tests := []struct {
+		name    string
+		data    func(t *testing.T) []byte
+		wantErr bool
+	}{
+		{"feature collection", func(t *testing.T) []byte {
+			return []byte(collection)
+		}, false},
+		{"newline delimited", func(t *testing.T) []byte {
+			return []byte(strings.Join([]string{featureRome, featureVatican, featureTokyo}, "\n") + "\n")
+		}, false},
+		{"text sequence", func(t *testing.T) []byte {
+			return []byte("\x1e" + featureRome + "\n\x1e" + featureVatican + "\n\x1e" + featureTokyo + "\n")
+		}, false},
+		{"features and collections", func(t *testing.T) []byte {
+			return []byte(featureRome + "\n" + `{"type":"FeatureCollection","features":[` + featureVatican + "," + featureTokyo + "]}")
+		}, false},
+		{"gzip", func(t *testing.T) []byte {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			_, err := zw.Write([]byte(collection))
+			assert.NoError(t, err)
+			assert.NoError(t, zw.Close())
+			return buf.Bytes()
+		}, false},
+		{"zip", func(t *testing.T) []byte {
+			var buf bytes.Buffer
+			zw := zip.NewWriter(&buf)
+			for name, content := range map[string]string{
+				"README.md":        "not GeoJSON",
+				"europe.geojson":   `{"type":"FeatureCollection","features":[` + featureRome + "," + featureVatican + "]}",
+				"asia/JAPAN.JSON":  featureTokyo,
+				"x":                "short name",
+				"empty/directory/": "",
+			} {
+				w, err := zw.Create(name)
+				assert.NoError(t, err)
+				_, err = w.Write([]byte(content))
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, zw.Close())
+			return buf.Bytes()
+		}, false},
+		{"binary", func(t *testing.T) []byte {
+			gsi, err := NewGeo2TzRTreeIndexFromReader(strings.NewReader(collection))
+			assert.NoError(t, err)
+			var buf bytes.Buffer
+			assert.NoError(t, gsi.WriteBinary(&buf))
+			return buf.Bytes()
+		}, false},
+		{"empty", func(t *testing.T) []byte {
+			return nil
+		}, true},
+		{"no features", func(t *testing.T) []byte {
+			return []byte(`{"type":"FeatureCollection","features":[]}`)
+		}, false},
+		{"not an object", func(t *testing.T) []byte {
+			return []byte(`[` + featureRome + `]`)
+		}, true},
+		{"invalid geometry", func(t *testing.T) []byte {
+			return []byte(`{"type":"Feature","properties":{"tzid":"Europe/Rome"},"geometry":{"type":"Polygon","coordinates":[[[12,41,0]]]}}`)
+		}, true},
+		{"truncated", func(t *testing.T) []byte {
+			return []byte(collection[:len(collection)/2])
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gsi, err := NewGeo2TzRTreeIndexFromReader(bytes.NewReader(tt.data(t)))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.name == "no features" {
+				assert.Equal(t, 0, gsi.Len())
+				return
+			}
+			assert.Equal(t, 3, gsi.Len())
+			for _, c := range []struct {
+				lat, lon float64
+				want     string
+			}{
+				{41.5, 12.7, "Europe/Rome"},
+				{41.9, 12.45, "Europe/Vatican"},
+				{35.5, 139.5, "Asia/Tokyo"},
+				{34.5, 135.5, "Asia/Tokyo"},
+			} {
+				got, err := gsi.Lookup(c.lat, c.lon)
+				assert.NoError(t, err)
+				assert.Equal(t, c.want, got)
+			}
+		})
+	}
+}
+
+func TestNewGeo2TzRTreeIndexFromReader_File(t *testing.T) {
+	// archives on disk are read in place
+	f, err := os.Open("testdata/timezones.zip")
+	assert.NoError(t, err)
+	defer f.Close()
+	gsi, err := NewGeo2TzRTreeIndexFromReader(f)
+	assert.NoError(t, err)
+
+	want, err := NewGeo2TzRTreeIndex("testdata/timezones.zip")
+	assert.NoError(t, err)
+	assert.Equal(t, want.zones, gsi.zones)
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"slices"
 	"strings"
 
 	"archive/zip"
@@ -12,10 +14,22 @@ import (
 	"github.com/tidwall/rtree"
 )
 
+// defaultMaxLookups is the maximum number of candidates tested for each lookup
+const defaultMaxLookups = 30
+
 type Geo2TzRTreeIndex struct {
 	max_lookups int
-	land        rtree.RTreeG[timezoneGeo]
-	sea         rtree.RTreeG[timezoneGeo]
+	// overrides are the custom zones that take precedence over land and sea
+	overrides rtree.RTreeG[timezoneGeo]
+	land      rtree.RTreeG[timezoneGeo]
+	sea       rtree.RTreeG[timezoneGeo]
+	zones     []timezoneGeo
+	// fallbackMaxDistance is the maximum distance in meters to search for the closest zone, 0 disables the fallback
+	fallbackMaxDistance float64
+	// nauticalFallback enables the nautical timezones for points that are not found
+	nauticalFallback bool
+	// observer is notified of the stats of each lookup
+	observer func(LookupStats)
 }
 
 // IsOcean checks if the timezone is for oceans
@@ -32,51 +46,144 @@ func (g *Geo2TzRTreeIndex) Insert(min, max [2]float64, element timezoneGeo) {
 	g.land.Insert(min, max, element)
 }
 
-// NewGeo2TzRTreeIndexFromGeoJSON creates a new Geo2TzRTreeIndex from a GeoJSON file
-func NewGeo2TzRTreeIndexFromGeoJSON(geoJSONPath string) (*Geo2TzRTreeIndex, error) {
-	// open the zip file
-	zipFile, err := zip.OpenReader(geoJSONPath)
+// add adds a timezone to the index, inserting the bounding box of each of its polygons
+func (g *Geo2TzRTreeIndex) add(tz timezoneGeo) {
+	g.zones = append(g.zones, tz)
+	for _, p := range tz.Polygons {
+		g.Insert([2]float64{p.MinLat, p.MinLng}, [2]float64{p.MaxLat, p.MaxLng}, tz)
+	}
+}
+
+// NewGeo2TzRTreeIndex creates a new Geo2TzRTreeIndex from a database file, the format
+// is detected from the content as in NewGeo2TzRTreeIndexFromReader
+func NewGeo2TzRTreeIndex(path string) (*Geo2TzRTreeIndex, error) {
+	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { 
-		if err := zipFile.Close(); err != nil {
-			fmt.Println("Error closing zip file:", err)
+	defer func() {
+		if err := f.Close(); err != nil {
+			fmt.Println("Error closing database file:", err)
 		}
 	}()
+	return NewGeo2TzRTreeIndexFromReader(f)
+}
+
+// NewGeo2TzRTreeIndexFromGeoJSON creates a new Geo2TzRTreeIndex from a GeoJSON file
+//
+// Deprecated: the format is detected from the content, use NewGeo2TzRTreeIndex.
+func NewGeo2TzRTreeIndexFromGeoJSON(geoJSONPath string) (*Geo2TzRTreeIndex, error) {
+	return NewGeo2TzRTreeIndex(geoJSONPath)
+}
+
+// Len returns the number of timezones in the index
+func (g *Geo2TzRTreeIndex) Len() int {
+	return len(g.zones)
+}
+
+// HasOceans checks if the index contains ocean timezones, datasets
+// without oceans have only the land index populated
+func (g *Geo2TzRTreeIndex) HasOceans() bool {
+	return g.sea.Len() > 0
+}
 
-	// create a new shape index
-	gri := &Geo2TzRTreeIndex{
-		max_lookups: 30,
+// SetFallbackMaxDistance enables the closest zone fallback for points within
+// the given distance in meters from a polygon edge, 0 disables the fallback
+func (g *Geo2TzRTreeIndex) SetFallbackMaxDistance(meters float64) {
+	g.fallbackMaxDistance = max(meters, 0)
+}
+
+// SetLookupObserver sets a function that is called with the stats of each lookup,
+// it must be set before the index is used concurrently
+func (g *Geo2TzRTreeIndex) SetLookupObserver(fn func(LookupStats)) {
+	g.observer = fn
+}
+
+// Lookup returns the timezone ID for a given latitude and longitude
+// if the timezone is not found, it returns an error
+func (g *Geo2TzRTreeIndex) Lookup(lat, lng float64) (string, error) {
+	r, err := g.Resolve(lat, lng)
+	return r.TzID, err
+}
+
+// Resolve returns the timezone for a given latitude and longitude
+// It first searches in the overrides, then in the land index, if not found, it searches in the sea index,
+// if still not found and the fallback is enabled, it returns the closest zone,
+// and finally, if the nautical fallback is enabled, the nautical timezone
+func (g *Geo2TzRTreeIndex) Resolve(lat, lng float64) (Result, error) {
+	var stats LookupStats
+	res, err := g.resolve(lat, lng, &stats)
+	if g.observer != nil {
+		g.observer(stats)
 	}
+	return res, err
+}
 
-	// this function will add the timezone polygons to the shape index
-	iter := func(tz *timezoneGeo) error {
-		for _, p := range tz.Polygons {
-			gri.Insert([2]float64{p.MinLat, p.MinLng}, [2]float64{p.MaxLat, p.MaxLng}, *tz)
+// resolve implements Resolve, collecting the lookup stats
+func (g *Geo2TzRTreeIndex) resolve(lat, lng float64, stats *LookupStats) (Result, error) {
+	var tzID string
+	first := func(id string) bool {
+		tzID = id
+		return false
+	}
+	if g.HasOverrides() {
+		if stats.Tested += g.search(&g.overrides, lat, lng, first); tzID != "" {
+			stats.Source = SourceOverride
+			return Result{TzID: tzID, Override: true}, nil
 		}
-		return nil
 	}
-	// iterate over the zip file
-	for _, v := range zipFile.File {
-		if strings.EqualFold(".json", v.Name[len(v.Name)-5:]) {
-			if err := decodeJSON(v, iter); err != nil {
-				return nil, err
-			}
+	if stats.Tested += g.search(&g.land, lat, lng, first); tzID != "" {
+		stats.Source = SourceLand
+		return Result{TzID: tzID}, nil
+	}
+	if stats.Tested += g.search(&g.sea, lat, lng, first); tzID != "" {
+		stats.Source = SourceSea
+		return Result{TzID: tzID}, nil
+	}
+	if g.fallbackMaxDistance > 0 {
+		if tzID, distance := g.closest(lat, lng, g.fallbackMaxDistance); tzID != "" {
+			stats.Source = SourceFallback
+			return Result{TzID: tzID, Fallback: true, Distance: distance}, nil
 		}
 	}
-	// build the shape index
-	return gri, nil
+	if g.nauticalFallback {
+		stats.Source = SourceNautical
+		return Result{TzID: NauticalTimezone(lng), Nautical: true}, nil
+	}
+	return Result{}, ErrNotFound
 }
 
-// Lookup returns the timezone ID for a given latitude and longitude
-// if the timezone is not found, it returns an error
-// It first searches in the land index, if not found, it searches in the sea index
-func (g *Geo2TzRTreeIndex) Lookup(lat, lng float64) (tzID string, err error) {
+// LookupAll returns the IDs of all the timezones with a polygon containing the given coordinates
+// if no timezone is found, it returns an error
+// as for Lookup, the sea index is searched only if there are no matches in the land index,
+// the matching overrides are listed first
+func (g *Geo2TzRTreeIndex) LookupAll(lat, lng float64) ([]string, error) {
+	var tzIDs []string
+	collect := func(tzID string) bool {
+		if !slices.Contains(tzIDs, tzID) {
+			tzIDs = append(tzIDs, tzID)
+		}
+		return true
+	}
+	if g.HasOverrides() {
+		g.search(&g.overrides, lat, lng, collect)
+	}
+	overrides := len(tzIDs)
+	if g.search(&g.land, lat, lng, collect); len(tzIDs) == overrides {
+		g.search(&g.sea, lat, lng, collect)
+	}
+	if len(tzIDs) == 0 {
+		return nil, ErrNotFound
+	}
+	return tzIDs, nil
+}
 
+// search calls fn with the ID of each timezone in the tree with a polygon containing the
+// given coordinates, until fn returns false or the maximum number of lookups is reached,
+// it returns the number of polygons tested
+func (g *Geo2TzRTreeIndex) search(tree *rtree.RTreeG[timezoneGeo], lat, lng float64, fn func(tzID string) bool) (tested int) {
 	lookup_num := 0
-	// search the land index
-	g.land.Search(
+	tree.Search(
 		[2]float64{lat, lng},
 		[2]float64{lat, lng},
 		func(min, max [2]float64, data timezoneGeo) bool {
@@ -85,51 +192,42 @@ func (g *Geo2TzRTreeIndex) Lookup(lat, lng float64) (tzID string, err error) {
 				return false
 			}
 			for _, p := range data.Polygons {
+				tested++
 				if isPointInPolygonPIP(vertex{lat, lng}, p) {
-					tzID = data.Name
-					return false
+					return fn(data.Name)
 				}
 			}
 			return true
 		},
 	)
-
-	if tzID == "" {
-		// if not found, search the sea index
-		lookup_num = 0
-		g.sea.Search(
-			[2]float64{lat, lng},
-			[2]float64{lat, lng},
-			func(min, max [2]float64, data timezoneGeo) bool {
-				lookup_num++
-				if lookup_num >= g.max_lookups {
-					return false
-				}
-				for _, p := range data.Polygons {
-					if isPointInPolygonPIP(vertex{lat, lng}, p) {
-						tzID = data.Name
-						return false
-					}
-				}
-				return true
-			},
-		)
-	}
-
-	if tzID == "" {
-		err = ErrNotFound
-	}
 	return
 }
 
 // isPointInPolygonPIP checks if a point is inside a polygon using the Point in Polygon algorithm
+// a point that falls inside one of the polygon holes is considered outside of the polygon
 func isPointInPolygonPIP(point vertex, polygon polygon) bool {
+	if !isPointInRing(point, polygon.Vertices) {
+		return false
+	}
+	for _, h := range polygon.Holes {
+		if !h.contains(point) {
+			continue
+		}
+		if isPointInRing(point, h.Vertices) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPointInRing checks if a point is inside a closed ring of vertices
+func isPointInRing(point vertex, vertices []vertex) bool {
 	oddNodes := false
-	n := len(polygon.Vertices)
+	n := len(vertices)
 	for i := 0; i < n; i++ {
 		j := (i + 1) % n
-		vi := polygon.Vertices[i]
-		vj := polygon.Vertices[j]
+		vi := vertices[i]
+		vj := vertices[j]
 		// Check if the point lies on an edge of the polygon (including horizontal)
 		if (vi.lng == vj.lng && vi.lng == point.lng && point.lat >= min(vi.lat, vj.lat) && point.lat <= max(vi.lat, vj.lat)) ||
 			((vi.lat < point.lat && point.lat <= vj.lat) || (vj.lat < point.lat && point.lat <= vi.lat)) &&
@@ -150,6 +248,7 @@ type timezoneGeo struct {
 }
 type polygon struct {
 	Vertices []vertex
+	Holes    []polygon
 	MaxLat   float64
 	MinLat   float64
 	MaxLng   float64
@@ -170,6 +269,11 @@ type vertex struct {
 	lat, lng float64
 }
 
+// contains checks if a point is within the polygon bounding box
+func (p *polygon) contains(point vertex) bool {
+	return point.lat >= p.MinLat && point.lat <= p.MaxLat && point.lng >= p.MinLng && point.lng <= p.MaxLng
+}
+
 func (p *polygon) AddVertex(lat, lng float64) {
 
 	if lat > p.MaxLat {
@@ -188,6 +292,7 @@ func (p *polygon) AddVertex(lat, lng float64) {
 	p.Vertices = append(p.Vertices, vertex{lat, lng})
 }
 
+// decodeJSON decodes the GeoJSON content of a zip archive entry
 func decodeJSON(f *zip.File, iter func(tz *timezoneGeo) error) (err error) {
 	var rc io.ReadCloser
 	if rc, err = f.Open(); err != nil {
@@ -198,86 +303,109 @@ func decodeJSON(f *zip.File, iter func(tz *timezoneGeo) error) (err error) {
 			fmt.Println("Error closing read closer:", err)
 		}
 	}()
+	return decodeStream(rc, iter)
+}
 
-	dec := json.NewDecoder(rc)
-
-	var token json.Token
-	for dec.More() {
-		if token, err = dec.Token(); err != nil {
+// decodeStream decodes a stream of GeoJSON objects, that can be either feature collections
+// or single features, separated by whitespace (as in newline delimited GeoJSON)
+// or by the record separator of GeoJSON text sequences (RFC 8142)
+func decodeStream(r io.Reader, iter func(tz *timezoneGeo) error) error {
+	dec := json.NewDecoder(recordSeparatorFilter{r})
+	found := false
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
 			break
 		}
-		if t, ok := token.(string); ok && t == "features" {
-			if token, err = dec.Token(); err == nil && token.(json.Delim) == '[' {
-				return decodeFeatures(dec, iter) // decode features
-			}
+		if err != nil {
+			return err
 		}
-	}
-	return errors.New("error no features found")
-}
-
-func decodeFeatures(dec *json.Decoder, fn func(tz *timezoneGeo) error) error {
-	var err error
-	toPolygon := func(raw any) (polygon, error) {
-		container, ok := raw.([]any)
-		if !ok {
-			return polygon{}, fmt.Errorf("invalid polygon data, expected[][]any, got %T", raw)
+		if d, ok := token.(json.Delim); !ok || d != '{' {
+			return fmt.Errorf("invalid GeoJSON data, expected an object, got %v", token)
 		}
 
-		p := newPolygon()
-		for _, c := range container {
-			c, ok := c.([]any)
-			if !ok {
-				return p, fmt.Errorf("invalid container data, expected []any, got %T", c)
-			}
-			if len(c) != 2 {
-				return p, fmt.Errorf("invalid point data, expected 2, got %v", len(c))
+		// the members of a feature are collected while looking for the features of a collection
+		var f geoFeature
+		isCollection := false
+		for dec.More() {
+			if token, err = dec.Token(); err != nil {
+				return err
 			}
-			lat, ok := c[1].(float64)
-			if !ok {
-				return p, fmt.Errorf("invalid lat data, float64, got %T", c)
+			switch token {
+			case "features":
+				if token, err = dec.Token(); err != nil || token != json.Delim('[') {
+					return fmt.Errorf("invalid GeoJSON features, expected an array, got %v", token)
+				}
+				if err = decodeFeatures(dec, iter); err != nil {
+					return err
+				}
+				// closing the array
+				if _, err = dec.Token(); err != nil {
+					return err
+				}
+				isCollection, found = true, true
+			case "type":
+				err = dec.Decode(&f.Type)
+			case "properties":
+				err = dec.Decode(&f.Properties)
+			case "geometry":
+				err = dec.Decode(&f.Geometry)
+			default:
+				var skip json.RawMessage
+				err = dec.Decode(&skip)
 			}
-			lng, ok := c[0].(float64)
-			if !ok {
-				return p, fmt.Errorf("invalid lng data, float64, got %T", c)
+			if err != nil {
+				return err
 			}
-			p.AddVertex(lat, lng)
 		}
-		return p, nil
+		// closing the object
+		if _, err = dec.Token(); err != nil {
+			return err
+		}
+
+		if isCollection || f.Type != "Feature" {
+			continue
+		}
+		tg, err := f.timezone()
+		if err != nil {
+			return err
+		}
+		if err = iter(tg); err != nil {
+			return err
+		}
+		found = true
 	}
+	if !found {
+		return errors.New("error no features found")
+	}
+	return nil
+}
+
+// recordSeparatorFilter replaces the record separators of GeoJSON text sequences with spaces
+type recordSeparatorFilter struct {
+	r io.Reader
+}
 
-	var f struct {
-		Type       string `json:"type"`
-		Properties struct {
-			TzID string `json:"tzid"`
-		} `json:"properties"`
-		Geometry struct {
-			Item        string `json:"type"`
-			Coordinates []any  `json:"coordinates"`
-		} `json:"geometry"`
+func (f recordSeparatorFilter) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	for i, b := range p[:n] {
+		if b == 0x1e {
+			p[i] = ' '
+		}
 	}
+	return n, err
+}
 
+// decodeFeatures decodes the elements of a GeoJSON features array
+func decodeFeatures(dec *json.Decoder, fn func(tz *timezoneGeo) error) error {
 	for dec.More() {
-		if err = dec.Decode(&f); err != nil {
+		var f geoFeature
+		if err := dec.Decode(&f); err != nil {
 			return err
 		}
-		tg := &timezoneGeo{Name: f.Properties.TzID}
-		switch f.Geometry.Item {
-		case "Polygon":
-			// we ignore the holes, that is why we only take the first block of coordinates
-			p, err := toPolygon(f.Geometry.Coordinates[0])
-			if err != nil {
-				return err
-			}
-			tg.Polygons = []polygon{p}
-		case "MultiPolygon":
-			for _, multi := range f.Geometry.Coordinates {
-				// we ignore the holes, that is why we only take the first block of coordinates
-				p, err := toPolygon(multi.([]any)[0])
-				if err != nil {
-					return err
-				}
-				tg.Polygons = append(tg.Polygons, p)
-			}
+		tg, err := f.timezone()
+		if err != nil {
+			return err
 		}
 		if err = fn(tg); err != nil {
 			return err
@@ -285,3 +413,87 @@ func decodeFeatures(dec *json.Decoder, fn func(tz *timezoneGeo) error) error {
 	}
 	return nil
 }
+
+// geoFeature is a GeoJSON feature with the timezone ID in the properties
+type geoFeature struct {
+	Type       string `json:"type"`
+	Properties struct {
+		TzID string `json:"tzid"`
+	} `json:"properties"`
+	Geometry struct {
+		Item        string `json:"type"`
+		Coordinates []any  `json:"coordinates"`
+	} `json:"geometry"`
+}
+
+// timezone converts the feature geometry into the timezone polygons
+func (f *geoFeature) timezone() (*timezoneGeo, error) {
+	tg := &timezoneGeo{Name: f.Properties.TzID}
+	switch f.Geometry.Item {
+	case "Polygon":
+		p, err := toPolygon(f.Geometry.Coordinates)
+		if err != nil {
+			return nil, err
+		}
+		tg.Polygons = []polygon{p}
+	case "MultiPolygon":
+		for _, multi := range f.Geometry.Coordinates {
+			p, err := toPolygon(multi)
+			if err != nil {
+				return nil, err
+			}
+			tg.Polygons = append(tg.Polygons, p)
+		}
+	}
+	return tg, nil
+}
+
+// toPolygon converts the rings of a GeoJSON polygon,
+// the first ring is the polygon boundary, the following ones are its holes
+func toPolygon(raw any) (polygon, error) {
+	rings, ok := raw.([]any)
+	if !ok || len(rings) == 0 {
+		return polygon{}, fmt.Errorf("invalid polygon rings, expected []any, got %T", raw)
+	}
+	p, err := toRing(rings[0])
+	if err != nil {
+		return p, err
+	}
+	for _, r := range rings[1:] {
+		h, err := toRing(r)
+		if err != nil {
+			return p, err
+		}
+		p.Holes = append(p.Holes, h)
+	}
+	return p, nil
+}
+
+// toRing converts a GeoJSON linear ring
+func toRing(raw any) (polygon, error) {
+	container, ok := raw.([]any)
+	if !ok {
+		return polygon{}, fmt.Errorf("invalid polygon data, expected[][]any, got %T", raw)
+	}
+
+	p := newPolygon()
+	for _, c := range container {
+		c, ok := c.([]any)
+		if !ok {
+			return p, fmt.Errorf("invalid container data, expected []any, got %T", c)
+		}
+		if len(c) != 2 {
+			return p, fmt.Errorf("invalid point data, expected 2, got %v", len(c))
+		}
+		lat, ok := c[1].(float64)
+		if !ok {
+			return p, fmt.Errorf("invalid lat data, float64, got %T", c)
+		}
+		lng, ok := c[0].(float64)
+		if !ok {
+			return p, fmt.Errorf("invalid lng data, float64, got %T", c)
+		}
+		p.AddVertex(lat, lng)
+	}
+	return p, nil
+}
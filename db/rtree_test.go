@@ -19,7 +19,7 @@ func TestGeo2TzTreeIndex_LookupZone(t *testing.T) {
 	}
 
 	// load the database
-	gsi, err := NewGeo2TzRTreeIndexFromGeoJSON("../tzdata/timezones.zip")
+	gsi, err := NewGeo2TzRTreeIndex("../tzdata/timezones.zip")
 	assert.NoError(t, err)
 
 	// load the coordinates
@@ -40,10 +40,103 @@ func TestGeo2TzTreeIndex_LookupZone(t *testing.T) {
 	}
 }
 
+// TestGeo2TzTreeIndex_LookupHoles tests that points inside a polygon hole are not matched by the polygon
+// the test dataset contains Europe/Rome, whose polygon has holes for Vatican City and San Marino,
+// but not the Europe/Vatican and Europe/San_Marino zones themselves
+func TestGeo2TzTreeIndex_LookupHoles(t *testing.T) {
+	gsi, err := NewGeo2TzRTreeIndex("testdata/timezones.zip")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		wantTz   string
+		wantErr  error
+	}{
+		{"Rome", 41.9028, 12.4964, "Europe/Rome", nil},
+		{"Vatican City", 41.9029, 12.4534, "", ErrNotFound},
+		{"San Marino", 43.9424, 12.4578, "", ErrNotFound},
+		{"Rimini", 44.0678, 12.5695, "Europe/Rome", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gsi.Lookup(tt.lat, tt.lon)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantTz, got)
+		})
+	}
+}
+
+// square returns a rectangular polygon for tests
+func square(minLat, minLng, maxLat, maxLng float64) polygon {
+	p := newPolygon()
+	p.AddVertex(minLat, minLng)
+	p.AddVertex(minLat, maxLng)
+	p.AddVertex(maxLat, maxLng)
+	p.AddVertex(maxLat, minLng)
+	p.AddVertex(minLat, minLng)
+	return p
+}
+
+func TestGeo2TzTreeIndex_LookupAll(t *testing.T) {
+	gsi := &Geo2TzRTreeIndex{max_lookups: defaultMaxLookups}
+	gsi.add(timezoneGeo{Name: "Zone/West", Polygons: []polygon{square(0, 0, 10, 10)}})
+	gsi.add(timezoneGeo{Name: "Zone/East", Polygons: []polygon{square(0, 8, 10, 20)}})
+	assert.False(t, gsi.HasOceans())
+	gsi.add(timezoneGeo{Name: "Etc/GMT-1", Polygons: []polygon{square(-10, 0, 0, 20)}})
+	assert.True(t, gsi.HasOceans())
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     []string
+		wantErr  error
+	}{
+		{"single land zone", 5, 2, []string{"Zone/West"}, nil},
+		{"overlapping land zones", 5, 9, []string{"Zone/West", "Zone/East"}, nil},
+		{"sea zone", -5, 5, []string{"Etc/GMT-1"}, nil},
+		{"not found", 50, 50, nil, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gsi.LookupAll(tt.lat, tt.lon)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.ElementsMatch(t, tt.want, got)
+			if err == nil {
+				// the first zone is the one returned by Lookup
+				tzID, err := gsi.Lookup(tt.lat, tt.lon)
+				assert.NoError(t, err)
+				assert.Equal(t, got[0], tzID)
+			}
+		})
+	}
+}
+
+func Test_isPointInPolygonPIP(t *testing.T) {
+	p := square(0, 0, 10, 10)
+	p.Holes = []polygon{square(4, 4, 6, 6)}
+
+	tests := []struct {
+		name  string
+		point vertex
+		want  bool
+	}{
+		{"inside", vertex{2, 2}, true},
+		{"outside", vertex{12, 2}, false},
+		{"inside the hole", vertex{5, 5}, false},
+		{"between the hole and the boundary", vertex{5, 8}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isPointInPolygonPIP(tt.point, p))
+		})
+	}
+}
+
 // benchmark the lookup function
 func BenchmarkGeo2TzTreeIndex_LookupZone(b *testing.B) {
 	// load the database
-	gsi, err := NewGeo2TzRTreeIndexFromGeoJSON("../tzdata/timezones.zip")
+	gsi, err := NewGeo2TzRTreeIndex("../tzdata/timezones.zip")
 	assert.NoError(b, err)
 
 	// load the coordinates
@@ -111,3 +204,30 @@ func Test_decodeJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestGeo2TzRTreeIndex_SetLookupObserver(t *testing.T) {
+	gsi := &Geo2TzRTreeIndex{max_lookups: defaultMaxLookups}
+	gsi.add(timezoneGeo{Name: "Europe/Rome", Polygons: []polygon{square(41, 12, 42, 13)}})
+	gsi.add(timezoneGeo{Name: "Etc/GMT-1", Polygons: []polygon{square(40, 10, 43, 15)}})
+
+	var got []LookupStats
+	gsi.SetLookupObserver(func(stats LookupStats) {
+		got = append(got, stats)
+	})
+	_, err := gsi.Lookup(41.5, 12.5)
+	assert.NoError(t, err)
+	_, err = gsi.Lookup(40.5, 10.5)
+	assert.NoError(t, err)
+	_, err = gsi.Lookup(0, 0)
+	assert.ErrorIs(t, err, ErrNotFound)
+	gsi.SetNauticalFallback(true)
+	_, err = gsi.Lookup(0, 0)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []LookupStats{
+		{Source: SourceLand, Tested: 1},
+		{Source: SourceSea, Tested: 1},
+		{Source: "", Tested: 0},
+		{Source: SourceNautical, Tested: 0},
+	}, got)
+}
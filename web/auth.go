@@ -0,0 +1,138 @@
+package web
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v5"
+)
+
+const (
+	// DefaultTokenName is the client name of the token set with auth_token_value
+	DefaultTokenName = "default"
+	// clientKey is the key of the authenticated client name in the request context
+	clientKey = "client"
+	// bearerScheme is the scheme of the tokens in the Authorization header
+	bearerScheme = "Bearer"
+)
+
+// authToken is the hash of a client token
+type authToken struct {
+	name string
+	hash []byte
+}
+
+// loadTokens collects the tokens set with auth_token_value, auth_tokens and auth_token_file
+func loadTokens(config WebSchema) ([]authToken, error) {
+	var tokens []authToken
+	if config.AuthTokenValue != "" {
+		tokens = append(tokens, authToken{name: DefaultTokenName, hash: hash(config.AuthTokenValue)})
+	}
+	// sort the names so that the tokens are always in the same order
+	names := make([]string, 0, len(config.AuthTokens))
+	for name := range config.AuthTokens {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if config.AuthTokens[name] == "" {
+			return nil, fmt.Errorf("%w: empty token for client %s", ErrorTokenFile, name)
+		}
+		tokens = append(tokens, authToken{name: name, hash: hash(config.AuthTokens[name])})
+	}
+	if config.AuthTokenFile != "" {
+		fileTokens, err := loadTokenFile(config.AuthTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, fileTokens...)
+	}
+	return tokens, nil
+}
+
+// loadTokenFile reads the tokens from a file with a NAME:TOKEN pair per line,
+// empty lines and lines starting with # are ignored
+func loadTokenFile(path string) ([]authToken, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrorTokenFile, err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			fmt.Println("Error closing token file:", err)
+		}
+	}()
+
+	var tokens []authToken
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, token, ok := strings.Cut(line, ":")
+		name, token = strings.TrimSpace(name), strings.TrimSpace(token)
+		if !ok || name == "" || token == "" {
+			// the line is not reported as it may contain a token
+			return nil, fmt.Errorf("%w: %s line %d, a NAME:TOKEN pair is required", ErrorTokenFile, path, n)
+		}
+		tokens = append(tokens, authToken{name: name, hash: hash(token)})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrorTokenFile, err)
+	}
+	return tokens, nil
+}
+
+// requestToken returns the token of the request, from the Authorization header,
+// the custom auth header or the query parameter, in this order
+func (server *Server) requestToken(c *echo.Context) string {
+	scheme, token, ok := strings.Cut(c.Request().Header.Get(echo.HeaderAuthorization), " ")
+	if ok && strings.EqualFold(scheme, bearerScheme) {
+		return strings.TrimSpace(token)
+	}
+	if name := server.config.Web.AuthHeaderName; name != "" {
+		if token := c.Request().Header.Get(name); token != "" {
+			return token
+		}
+	}
+	if name := server.config.Web.AuthTokenParamName; name != "" {
+		return c.QueryParam(name)
+	}
+	return ""
+}
+
+// authenticate returns the name of the client owning the request token
+func (server *Server) authenticate(c *echo.Context) (client string, ok bool) {
+	return server.authenticateToken(server.requestToken(c))
+}
+
+// authenticateToken returns the name of the client owning the token
+func (server *Server) authenticateToken(token string) (client string, ok bool) {
+	if token == "" {
+		return "", false
+	}
+	// all the tokens are compared to not leak which one matched
+	for _, t := range server.authTokens {
+		if isEq(t.hash, token) && !ok {
+			client, ok = t.name, true
+		}
+	}
+	return
+}
+
+// isAdmin checks if the client is one of the admin clients
+func (server *Server) isAdmin(client string) bool {
+	return slices.ContainsFunc(server.config.Web.AdminClients, func(name string) bool {
+		return strings.EqualFold(strings.TrimSpace(name), client)
+	})
+}
+
+// requestClient returns the name of the authenticated client of the request, if any
+func requestClient(c *echo.Context) string {
+	client, _ := c.Get(clientKey).(string)
+	return client
+}
@@ -0,0 +1,136 @@
+package web
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_loadTokenFile(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := loadTokenFile(filepath.Join(dir, "not_found"))
+	assert.ErrorIs(t, err, ErrorTokenFile)
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{"tokens", "# clients\nalice:s3cret\n\n  bob : t0ken  \n", []string{"alice", "bob"}, false},
+		{"colons in token", "alice:s3:cr:et\n", []string{"alice"}, false},
+		{"empty", "", nil, false},
+		{"missing name", ":s3cret\n", nil, true},
+		{"missing token", "alice:\n", nil, true},
+		{"missing separator", "s3cret\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "tokens")
+			assert.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+			got, err := loadTokenFile(path)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrorTokenFile)
+				assert.NotContains(t, err.Error(), "s3cret")
+				return
+			}
+			assert.NoError(t, err)
+			var names []string
+			for _, token := range got {
+				names = append(names, token.name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestServer_isAuthorized(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "tokens")
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("carol:c4rol\n"), 0o600))
+
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  "../tzdata/version.json",
+			DatabaseName: "../db/testdata/timezones.zip",
+		},
+		Web: WebSchema{
+			AuthTokenValue:     "secret",
+			AuthTokenParamName: "t",
+			AuthHeaderName:     "X-API-Key",
+			AuthTokens:         map[string]string{"alice": "al1ce", "bob": "b0b"},
+			AuthTokenFile:      tokenFile,
+			MetricsEnabled:     true,
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+	var logs bytes.Buffer
+	server.echo.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	tests := []struct {
+		name       string
+		target     string
+		header     http.Header
+		wantCode   int
+		wantClient string
+	}{
+		{"no token", "/tz/41.9028/12.4964", nil, http.StatusUnauthorized, ""},
+		{"invalid token", "/tz/41.9028/12.4964", http.Header{"Authorization": {"Bearer nope"}}, http.StatusUnauthorized, ""},
+		{"default token in query", "/tz/41.9028/12.4964?t=secret", nil, http.StatusOK, DefaultTokenName},
+		{"bearer", "/tz/41.9028/12.4964", http.Header{"Authorization": {"Bearer al1ce"}}, http.StatusOK, "alice"},
+		{"bearer lowercase", "/tz/41.9028/12.4964", http.Header{"Authorization": {"bearer b0b"}}, http.StatusOK, "bob"},
+		{"basic is not bearer", "/tz/41.9028/12.4964", http.Header{"Authorization": {"Basic al1ce"}}, http.StatusUnauthorized, ""},
+		{"custom header", "/tz/41.9028/12.4964", http.Header{"X-Api-Key": {"c4rol"}}, http.StatusOK, "carol"},
+		{"header before query", "/tz/41.9028/12.4964?t=secret", http.Header{"Authorization": {"Bearer b0b"}}, http.StatusOK, "bob"},
+		{"batch", "/tz/batch", http.Header{"Authorization": {"Bearer al1ce"}}, http.StatusOK, "alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			method, body := http.MethodGet, ""
+			if tt.target == "/tz/batch" {
+				method, body = http.MethodPost, `[{"lat":41.9028,"lon":12.4964}]`
+			}
+			req := httptest.NewRequest(method, tt.target, bytes.NewBufferString(body))
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+			server.echo.ServeHTTP(rec, req)
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantClient != "" {
+				assert.Contains(t, logs.String(), `"client":"`+tt.wantClient+`"`)
+			} else {
+				assert.NotContains(t, logs.String(), `"client"`)
+			}
+		})
+	}
+
+	// the client is attached to the metrics
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	server.echo.ServeHTTP(rec, req)
+	assert.Contains(t, rec.Body.String(), `geo2tz_http_requests_total{client="alice",method="GET",route="/tz/:lat/:lon",status="200"} 1`)
+	assert.Contains(t, rec.Body.String(), `geo2tz_http_requests_total{client="anonymous",method="GET",route="/tz/:lat/:lon",status="401"} 3`)
+
+	// the query parameter can be disabled
+	settings.Web.AuthTokenParamName = ""
+	server, err = NewServer(settings)
+	assert.NoError(t, err)
+	req = httptest.NewRequest(http.MethodGet, "/tz/41.9028/12.4964?t=secret", nil)
+	rec = httptest.NewRecorder()
+	server.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// invalid tokens prevent the server from starting
+	settings.Web.AuthTokens = map[string]string{"alice": ""}
+	_, err = NewServer(settings)
+	assert.ErrorIs(t, err, ErrorTokenFile)
+}
@@ -2,6 +2,8 @@ package web
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -10,35 +12,114 @@ const (
 	TZDBFile      = "tzdata/timezones.zip"
 	TZVersionFile = "tzdata/version.json"
 
-	GeoDataURLTemplate        = "https://github.com/evansiroky/timezone-boundary-builder/releases/download/%s/timezones-with-oceans.geojson.zip"
+	// ReleasesURL is the base url of the timezone data releases
+	ReleasesURL = "https://github.com/evansiroky/timezone-boundary-builder/releases"
+
+	// GeoDataURLTemplate is the url of the data of the default variant of an upstream release
+	//
+	// Deprecated: the url depends on the releases url and the variant, use NewTzReleaseFrom.
+	GeoDataURLTemplate = "https://github.com/evansiroky/timezone-boundary-builder/releases/download/%s/timezones-with-oceans.geojson.zip"
+	// GeoDataReleaseURLTemplate is the url of an upstream release
+	//
+	// Deprecated: the url depends on the releases url, use NewTzReleaseFrom.
 	GeoDataReleaseURLTemplate = "https://github.com/evansiroky/timezone-boundary-builder/releases/tag/%s"
+
+	// geoDataURLTemplate is the url of the data of a variant, relative to the releases url
+	geoDataURLTemplate = "%s/download/%s/%s"
+	// geoDataReleaseURLTemplate is the url of a release, relative to the releases url
+	geoDataReleaseURLTemplate = "%s/tag/%s"
+)
+
+// dataset variants published upstream, the "now" and "1970" variants merge
+// the zones that have agreed on the same time since now or since 1970
+const (
+	VariantWithOceans     = "with-oceans"
+	VariantWithOceansNow  = "with-oceans-now"
+	VariantWithOceans1970 = "with-oceans-1970"
+	VariantLand           = "land"
+	VariantLandNow        = "now"
+	VariantLand1970       = "1970"
 )
 
+// variantFiles maps the dataset variants to the file names published upstream
+var variantFiles = map[string]string{
+	VariantWithOceans:     "timezones-with-oceans.geojson.zip",
+	VariantWithOceansNow:  "timezones-with-oceans-now.geojson.zip",
+	VariantWithOceans1970: "timezones-with-oceans-1970.geojson.zip",
+	VariantLand:           "timezones.geojson.zip",
+	VariantLandNow:        "timezones-now.geojson.zip",
+	VariantLand1970:       "timezones-1970.geojson.zip",
+}
+
+// ValidateVariant checks that the variant is one of the dataset variants published upstream
+func ValidateVariant(variant string) error {
+	if _, ok := variantFiles[variant]; !ok {
+		return fmt.Errorf("unknown dataset variant %q, valid variants are: %s, %s, %s, %s, %s, %s", variant,
+			VariantWithOceans, VariantWithOceansNow, VariantWithOceans1970, VariantLand, VariantLandNow, VariantLand1970)
+	}
+	return nil
+}
+
+// isLandVariant checks if the dataset variant covers only the land, without the ocean zones
+func isLandVariant(variant string) bool {
+	return variant == VariantLand || variant == VariantLandNow || variant == VariantLand1970
+}
+
 type TzRelease struct {
 	Version    string `json:"version"`
 	URL        string `json:"url"`
 	GeoDataURL string `json:"geo_data_url"`
+	Variant    string `json:"variant,omitempty"`
+	SHA256     string `json:"sha256,omitempty"`
 }
 
+// NewTzRelease returns the release info for a version of the default variant published at the default releases url
 func NewTzRelease(version string) TzRelease {
+	return NewTzReleaseFrom(ReleasesURL, version, VariantWithOceans)
+}
+
+// NewTzReleaseFrom returns the release info for a version of a dataset variant published at releasesURL,
+// that may point to a mirror of the upstream releases
+func NewTzReleaseFrom(releasesURL, version, variant string) TzRelease {
+	releasesURL = strings.TrimSuffix(releasesURL, "/")
 	return TzRelease{
 		Version:    version,
-		URL:        fmt.Sprintf(GeoDataReleaseURLTemplate, version),
-		GeoDataURL: fmt.Sprintf(GeoDataURLTemplate, version),
+		URL:        fmt.Sprintf(geoDataReleaseURLTemplate, releasesURL, version),
+		GeoDataURL: fmt.Sprintf(geoDataURLTemplate, releasesURL, version, variantFiles[variant]),
+		Variant:    variant,
 	}
 }
 
 // TzSchema configuration
 type TzSchema struct {
-	DatabaseName string `mapstructure:"database_name"`
-	VersionFile  string `mapstructure:"version_file"`
+	DatabaseName        string        `mapstructure:"database_name"`
+	VersionFile         string        `mapstructure:"version_file"`
+	FallbackMaxDistance float64       `mapstructure:"fallback_max_distance"`
+	NauticalFallback    bool          `mapstructure:"nautical_fallback"`
+	ReleasesURL         string        `mapstructure:"releases_url"`
+	Variant             string        `mapstructure:"variant"`
+	AutoUpdateInterval  time.Duration `mapstructure:"auto_update_interval"`
+	OverridesFile       string        `mapstructure:"overrides_file"`
 }
 
 // WebSchema configuration
 type WebSchema struct {
-	ListenAddress      string `mapstructure:"listen_address,omitempty"`
-	AuthTokenValue     string `mapstructure:"auth_token_value,omitempty"`
-	AuthTokenParamName string `mapstructure:"auth_token_param_name,omitempty"`
+	ListenAddress      string            `mapstructure:"listen_address,omitempty"`
+	AuthTokenValue     string            `mapstructure:"auth_token_value,omitempty"`
+	AuthTokenParamName string            `mapstructure:"auth_token_param_name,omitempty"`
+	AuthHeaderName     string            `mapstructure:"auth_header_name,omitempty"`
+	AuthTokens         map[string]string `mapstructure:"auth_tokens,omitempty"`
+	AuthTokenFile      string            `mapstructure:"auth_token_file,omitempty"`
+	BatchMaxSize       int               `mapstructure:"batch_max_size,omitempty"`
+	MetricsEnabled     bool              `mapstructure:"metrics_enabled,omitempty"`
+	ShutdownTimeout    time.Duration     `mapstructure:"shutdown_timeout,omitempty"`
+	RateLimit          float64           `mapstructure:"rate_limit,omitempty"`
+	RateLimitBurst     int               `mapstructure:"rate_limit_burst,omitempty"`
+	DailyQuota         int               `mapstructure:"daily_quota,omitempty"`
+	Privacy            bool              `mapstructure:"privacy"`
+	PrivacyPrecision   int               `mapstructure:"privacy_precision"`
+	GRPCListenAddress  string            `mapstructure:"grpc_listen_address,omitempty"`
+	AdminClients       []string          `mapstructure:"admin_clients,omitempty"`
 }
 
 // ConfigSchema main configuration for the news room
@@ -48,15 +129,53 @@ type ConfigSchema struct {
 	RuntimeVersion string    `mapstructure:"-"`
 }
 
+// redacted replaces the value of the secrets in the configuration
+const redacted = "[redacted]"
+
+// Redacted returns a copy of the configuration with the auth tokens redacted, so it can be logged,
+// the names of the clients are kept
+func (c ConfigSchema) Redacted() ConfigSchema {
+	if c.Web.AuthTokenValue != "" {
+		c.Web.AuthTokenValue = redacted
+	}
+	if c.Web.AuthTokens != nil {
+		tokens := make(map[string]string, len(c.Web.AuthTokens))
+		for client := range c.Web.AuthTokens {
+			tokens[client] = redacted
+		}
+		c.Web.AuthTokens = tokens
+	}
+	return c
+}
+
 // Defaults configure defaults
 func Defaults() {
 	// tz defaults
 	viper.SetDefault("tz.database_name", TZDBFile)
 	viper.SetDefault("tz.version_file", TZVersionFile)
+	viper.SetDefault("tz.fallback_max_distance", 0) // meters, 0 disables the closest zone fallback
+	viper.SetDefault("tz.nautical_fallback", false)
+	viper.SetDefault("tz.releases_url", ReleasesURL)
+	viper.SetDefault("tz.variant", VariantWithOceans)
+	viper.SetDefault("tz.auto_update_interval", 0) // eg. 24h, 0 disables the background updates
+	viper.SetDefault("tz.overrides_file", "")      // GeoJSON zones that take precedence over the dataset
 	// web
 	viper.SetDefault("web.listen_address", ":2004")
-	viper.SetDefault("web.auth_token_value", "") // GEO2TZ_WEB_AUTH_TOKEN_VALUE="ciao"
-	viper.SetDefault("web.auth_token_param_name", "t")
+	viper.SetDefault("web.auth_token_value", "")       // GEO2TZ_WEB_AUTH_TOKEN_VALUE="ciao"
+	viper.SetDefault("web.auth_token_param_name", "t") // empty to accept the token only in the headers
+	viper.SetDefault("web.auth_header_name", "X-API-Key")
+	viper.SetDefault("web.auth_token_file", "") // NAME:TOKEN pairs, one per line
+	viper.SetDefault("web.batch_max_size", 1000)
+	viper.SetDefault("web.metrics_enabled", false)
+	viper.SetDefault("web.shutdown_timeout", teardownTimeout) // time to drain the in-flight requests
+	viper.SetDefault("web.rate_limit", 0)                     // coordinates per second per client, 0 disables the limit
+	viper.SetDefault("web.rate_limit_burst", 0)               // 0 defaults to one second worth of coordinates
+	viper.SetDefault("web.daily_quota", 0)                    // coordinates per day per client, 0 disables the quota
+	viper.SetDefault("web.privacy", true)                     // keep the coordinates and the tokens out of the logs
+	viper.SetDefault("web.privacy_precision", 1)              // decimals of the coordinates in the logs, -1 to redact them
+	viper.SetDefault("web.grpc_listen_address", "")           // eg. :2005, empty disables the gRPC API
+	// clients allowed on the admin routes, the token of auth_token_value by default
+	viper.SetDefault("web.admin_clients", []string{DefaultTokenName})
 }
 
 // Validate a configuration
@@ -0,0 +1,77 @@
+package web
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTzReleaseFrom(t *testing.T) {
+	tests := []struct {
+		name        string
+		releasesURL string
+		variant     string
+		want        TzRelease
+	}{
+		{
+			"default variant",
+			ReleasesURL,
+			VariantWithOceans,
+			TzRelease{
+				Version:    "2024a",
+				URL:        "https://github.com/evansiroky/timezone-boundary-builder/releases/tag/2024a",
+				GeoDataURL: "https://github.com/evansiroky/timezone-boundary-builder/releases/download/2024a/timezones-with-oceans.geojson.zip",
+				Variant:    VariantWithOceans,
+			},
+		},
+		{
+			"land variant from a mirror",
+			"http://mirror.local/releases/",
+			VariantLandNow,
+			TzRelease{
+				Version:    "2024a",
+				URL:        "http://mirror.local/releases/tag/2024a",
+				GeoDataURL: "http://mirror.local/releases/download/2024a/timezones-now.geojson.zip",
+				Variant:    VariantLandNow,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewTzReleaseFrom(tt.releasesURL, "2024a", tt.variant))
+		})
+	}
+	assert.Equal(t, NewTzReleaseFrom(ReleasesURL, "2024a", VariantWithOceans), NewTzRelease("2024a"))
+	// the deprecated templates are of the default releases
+	assert.Equal(t, fmt.Sprintf(GeoDataURLTemplate, "2024a"), NewTzRelease("2024a").GeoDataURL)
+	assert.Equal(t, fmt.Sprintf(GeoDataReleaseURLTemplate, "2024a"), NewTzRelease("2024a").URL)
+}
+
+func TestValidateVariant(t *testing.T) {
+	for variant := range variantFiles {
+		assert.NoError(t, ValidateVariant(variant))
+	}
+	assert.Error(t, ValidateVariant(""))
+	assert.Error(t, ValidateVariant("oceans"))
+}
+
+func TestConfigSchema_Redacted(t *testing.T) {
+	config := ConfigSchema{
+		Web: WebSchema{
+			AuthTokenValue: "secret",
+			AuthTokens:     map[string]string{"alice": "alice-secret", "bob": "bob-secret"},
+			AuthTokenFile:  "/etc/geo2tz/tokens",
+		},
+	}
+	got := config.Redacted()
+	assert.Equal(t, "[redacted]", got.Web.AuthTokenValue)
+	assert.Equal(t, map[string]string{"alice": "[redacted]", "bob": "[redacted]"}, got.Web.AuthTokens)
+	assert.Equal(t, "/etc/geo2tz/tokens", got.Web.AuthTokenFile)
+	assert.NotContains(t, fmt.Sprintf("%#v", got), "secret")
+	// the configuration is not changed
+	assert.Equal(t, "secret", config.Web.AuthTokenValue)
+	assert.Equal(t, "alice-secret", config.Web.AuthTokens["alice"])
+	// no tokens are left as they are
+	assert.Equal(t, ConfigSchema{}, ConfigSchema{}.Redacted())
+}
@@ -7,4 +7,7 @@ var (
 	ErrorVersionFileInvalid   = errors.New("release version file invalid")
 	ErrorDatabaseFileNotFound = errors.New("database file not found")
 	ErrorDatabaseFileInvalid  = errors.New("database file invalid")
+	ErrorOverridesFile        = errors.New("overrides file invalid")
+	ErrorTokenFile            = errors.New("auth tokens invalid")
+	ErrorShutdownTimeout      = errors.New("shutdown timeout, in-flight requests dropped")
 )
@@ -0,0 +1,303 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/noandrea/geo2tz/v2/api"
+	"github.com/noandrea/geo2tz/v2/db"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcMethod is the method label of the gRPC calls in the metrics
+const grpcMethod = "GRPC"
+
+// grpcClientKey is the key of the authenticated client name in the context of the gRPC calls
+type grpcClientKey struct{}
+
+// grpcService implements the gRPC API on the same dataset of the REST API
+type grpcService struct {
+	api.UnimplementedGeo2TzServer
+	server *Server
+}
+
+// newGRPCServer returns the gRPC server, sharing the authorization, the rate limits,
+// the logs and the metrics with the REST API
+func (server *Server) newGRPCServer() *grpc.Server {
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(server.grpcUnaryInterceptor),
+		grpc.ChainStreamInterceptor(server.grpcStreamInterceptor),
+	)
+	api.RegisterGeo2TzServer(s, &grpcService{server: server})
+	return s
+}
+
+// serveGRPC serves the gRPC API until the server is stopped, waiting for the in-flight
+// calls to complete within the shutdown timeout
+func (server *Server) serveGRPC(lis net.Listener, timeout time.Duration) error {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-server.shutdownCtx.Done()
+		graceful := make(chan struct{})
+		go func() {
+			server.grpcServer.GracefulStop()
+			close(graceful)
+		}()
+		select {
+		case <-graceful:
+		case <-time.After(timeout):
+			server.grpcShutdownErr = fmt.Errorf("grpc: %w", context.DeadlineExceeded)
+			server.grpcServer.Stop()
+		}
+	}()
+	// the server may be stopped before serving, if the shutdown is immediate
+	err := server.grpcServer.Serve(lis)
+	if errors.Is(err, grpc.ErrServerStopped) {
+		err = nil
+	}
+	if err != nil {
+		// stop the REST API too, the server is not usable anymore
+		server.cancel()
+	}
+	<-stopped
+	return err
+}
+
+// grpcToken returns the token of a gRPC call, from the authorization metadata
+// or the custom auth header metadata, in this order
+func (server *Server) grpcToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		scheme, token, ok := strings.Cut(v, " ")
+		if ok && strings.EqualFold(scheme, bearerScheme) {
+			return strings.TrimSpace(token)
+		}
+	}
+	if name := server.config.Web.AuthHeaderName; name != "" {
+		if v := md.Get(name); len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// grpcAuthorize verifies the token of a gRPC call, if the authorization is enabled,
+// and returns the context with the name of the authenticated client
+func (server *Server) grpcAuthorize(ctx context.Context) (context.Context, error) {
+	if !server.authEnabled {
+		return ctx, nil
+	}
+	client, ok := server.authenticateToken(server.grpcToken(ctx))
+	if !ok {
+		// the token is never logged
+		server.echo.Logger.Error("request unauthorized, invalid token")
+		return ctx, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	return context.WithValue(ctx, grpcClientKey{}, client), nil
+}
+
+// grpcClient returns the name of the authenticated client of a gRPC call, if any
+func grpcClient(ctx context.Context) string {
+	client, _ := ctx.Value(grpcClientKey{}).(string)
+	return client
+}
+
+// grpcRemoteIP returns the address of the peer of a gRPC call, without the port
+func grpcRemoteIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// grpcRateLimit checks if a gRPC call can query n coordinates, with the same limits of the REST API
+func (server *Server) grpcRateLimit(ctx context.Context, n int) error {
+	if _, err := server.checkRateLimit(rateLimitKey(grpcClient(ctx), grpcRemoteIP(ctx)), n); err != nil {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return nil
+}
+
+// logGRPC logs and counts a gRPC call as the REST requests, redacting the error message
+func (server *Server) logGRPC(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	client := grpcClient(ctx)
+	server.metrics.observeRequest(method, grpcMethod, code.String(), client)
+	attrs := []slog.Attr{
+		slog.String("method", grpcMethod),
+		slog.String("uri", method),
+		slog.String("status", code.String()),
+		slog.Duration("latency", time.Since(start)),
+		slog.String("remote_ip", grpcRemoteIP(ctx)),
+	}
+	if client != "" {
+		attrs = append(attrs, slog.String(clientKey, client))
+	}
+	if err == nil {
+		server.echo.Logger.LogAttrs(ctx, slog.LevelInfo, "REQUEST", attrs...)
+		return
+	}
+	attrs = append(attrs, slog.String("error", server.redactText(status.Convert(err).Message())))
+	server.echo.Logger.LogAttrs(ctx, slog.LevelError, "REQUEST_ERROR", attrs...)
+}
+
+// grpcUnaryInterceptor authorizes, logs and counts the unary gRPC calls
+func (server *Server) grpcUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx, err := server.grpcAuthorize(ctx)
+	var resp any
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+	server.logGRPC(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+// authorizedStream is a gRPC stream with the context of the authenticated client
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+// grpcStreamInterceptor authorizes, logs and counts the streaming gRPC calls
+func (server *Server) grpcStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, err := server.grpcAuthorize(ss.Context())
+	if err == nil {
+		err = handler(srv, &authorizedStream{ServerStream: ss, ctx: ctx})
+	}
+	server.logGRPC(ctx, info.FullMethod, start, err)
+	return err
+}
+
+// Lookup returns the timezone of a pair of coordinates
+func (s *grpcService) Lookup(ctx context.Context, req *api.LookupRequest) (*api.LookupResponse, error) {
+	if err := s.server.grpcRateLimit(ctx, 1); err != nil {
+		return nil, err
+	}
+	resp := s.server.grpcLookup(s.server.dataset.Load().tzDB, req)
+	if resp.Error != nil {
+		return nil, status.Error(codes.Code(resp.Error.Code), resp.Error.Message)
+	}
+	return resp, nil
+}
+
+// BatchLookup returns the timezones of a list of coordinates, using the same database for all of them
+func (s *grpcService) BatchLookup(ctx context.Context, req *api.BatchLookupRequest) (*api.BatchLookupResponse, error) {
+	items := req.GetItems()
+	if len(items) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "empty batch request")
+	}
+	if maxSize := s.server.config.Web.BatchMaxSize; maxSize > 0 && len(items) > maxSize {
+		return nil, status.Errorf(codes.InvalidArgument, "batch size %d exceeds the maximum of %d", len(items), maxSize)
+	}
+	// batches are limited by the number of coordinates
+	if err := s.server.grpcRateLimit(ctx, len(items)); err != nil {
+		return nil, err
+	}
+	tzDB := s.server.dataset.Load().tzDB
+	resp := &api.BatchLookupResponse{Items: make([]*api.LookupResponse, len(items))}
+	for i, item := range items {
+		resp.Items[i] = s.server.grpcLookup(tzDB, item)
+	}
+	return resp, nil
+}
+
+// StreamLookup returns a response for each of the coordinates received, the rate limits
+// are applied to each of them and the database reloads are picked up while streaming
+func (s *grpcService) StreamLookup(stream api.Geo2Tz_StreamLookupServer) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var resp *api.LookupResponse
+		if err := s.server.grpcRateLimit(stream.Context(), 1); err != nil {
+			resp = &api.LookupResponse{Id: req.GetId(), Lat: req.GetLat(), Lon: req.GetLon(), Error: newGRPCError(err)}
+		} else {
+			resp = s.server.grpcLookup(s.server.dataset.Load().tzDB, req)
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// Version returns the release of the timezone database in use
+func (s *grpcService) Version(context.Context, *api.VersionRequest) (*api.VersionResponse, error) {
+	r := s.server.Release()
+	return &api.VersionResponse{
+		Version:    r.Version,
+		Url:        r.URL,
+		GeoDataUrl: r.GeoDataURL,
+		Variant:    r.Variant,
+		Sha256:     r.SHA256,
+	}, nil
+}
+
+// grpcLookup validates and queries the coordinates of a gRPC request,
+// the errors are reported in the response
+func (server *Server) grpcLookup(tzDB db.TzDBIndex, req *api.LookupRequest) *api.LookupResponse {
+	resp := &api.LookupResponse{Id: req.GetId(), Lat: req.GetLat(), Lon: req.GetLon()}
+	// the coordinates are validated as the ones of the REST API
+	lat, err := parseCoordinate(strconv.FormatFloat(req.GetLat(), 'f', -1, 64), Latitude)
+	if err != nil {
+		resp.Error = newGRPCError(status.Error(codes.InvalidArgument, err.Error()))
+		return resp
+	}
+	lon, err := parseCoordinate(strconv.FormatFloat(req.GetLon(), 'f', -1, 64), Longitude)
+	if err != nil {
+		resp.Error = newGRPCError(status.Error(codes.InvalidArgument, err.Error()))
+		return resp
+	}
+	res, err := server.resolve(tzDB, lat, lon)
+	switch {
+	case err == nil:
+		resp.Tz = res.TzID
+		resp.Override = res.Override
+		resp.Fallback = res.Fallback
+		if res.Fallback {
+			resp.DistanceMeters = math.Round(res.Distance*10) / 10
+		}
+		resp.Nautical = res.Nautical
+	case errors.Is(err, db.ErrNotFound):
+		notFoundErr := fmt.Errorf("timezone not found for coordinates %f,%f", lat, lon)
+		server.logError("error querying the timezone db", notFoundErr)
+		resp.Error = newGRPCError(status.Error(codes.NotFound, notFoundErr.Error()))
+	default:
+		server.logError("error querying the timezone db", err)
+		resp.Error = newGRPCError(status.Error(codes.Internal, err.Error()))
+	}
+	return resp
+}
+
+// newGRPCError returns the error of a single response from a gRPC status error
+func newGRPCError(err error) *api.Error {
+	s := status.Convert(err)
+	return &api.Error{Code: uint32(s.Code()), Message: s.Message()}
+}
@@ -0,0 +1,194 @@
+package web
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/noandrea/geo2tz/v2/api"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCTestClient serves the gRPC API of the server in memory and returns a client
+func newGRPCTestClient(t *testing.T, server *Server) api.Geo2TzClient {
+	lis := bufconn.Listen(1024 * 1024)
+	go func() { _ = server.grpcServer.Serve(lis) }()
+	t.Cleanup(server.grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return api.NewGeo2TzClient(conn)
+}
+
+func TestServer_GRPC(t *testing.T) {
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  "../tzdata/version.json",
+			DatabaseName: "../db/testdata/timezones.zip",
+		},
+		Web: WebSchema{
+			AuthTokens:        map[string]string{"alice": "al1ce"},
+			AuthHeaderName:    "X-API-Key",
+			BatchMaxSize:      3,
+			MetricsEnabled:    true,
+			GRPCListenAddress: "bufconn",
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+	client := newGRPCTestClient(t, server)
+	ctx := context.Background()
+	authorized := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer al1ce")
+
+	// the token is checked as for the REST API
+	_, err = client.Version(ctx, &api.VersionRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.Version(metadata.AppendToOutgoingContext(ctx, "x-api-key", "nope"), &api.VersionRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	v, err := client.Version(metadata.AppendToOutgoingContext(ctx, "x-api-key", "al1ce"), &api.VersionRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, server.Release().Version, v.GetVersion())
+	assert.Equal(t, server.Release().GeoDataURL, v.GetGeoDataUrl())
+
+	// lookup
+	resp, err := client.Lookup(authorized, &api.LookupRequest{Id: "rome", Lat: 41.9028, Lon: 12.4964})
+	assert.NoError(t, err)
+	assert.Equal(t, "rome", resp.GetId())
+	assert.Equal(t, "Europe/Rome", resp.GetTz())
+	assert.Nil(t, resp.GetError())
+	_, err = client.Lookup(authorized, &api.LookupRequest{Lat: 91, Lon: 0})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "lat value 91 out of range")
+	_, err = client.Lookup(authorized, &api.LookupRequest{Lat: -77.85, Lon: 166.67})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// batch lookup, the errors are reported per item
+	batch, err := client.BatchLookup(authorized, &api.BatchLookupRequest{Items: []*api.LookupRequest{
+		{Id: "1", Lat: 41.9028, Lon: 12.4964},
+		{Id: "2", Lat: 41.9028, Lon: 181},
+	}})
+	assert.NoError(t, err)
+	assert.Len(t, batch.GetItems(), 2)
+	assert.Equal(t, "Europe/Rome", batch.GetItems()[0].GetTz())
+	assert.Equal(t, "2", batch.GetItems()[1].GetId())
+	assert.Equal(t, uint32(codes.InvalidArgument), batch.GetItems()[1].GetError().GetCode())
+	_, err = client.BatchLookup(authorized, &api.BatchLookupRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.BatchLookup(authorized, &api.BatchLookupRequest{Items: make([]*api.LookupRequest, 4)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// stream lookup, the responses are in the order of the requests
+	stream, err := client.StreamLookup(authorized)
+	assert.NoError(t, err)
+	requests := []*api.LookupRequest{
+		{Id: "a", Lat: 41.9028, Lon: 12.4964},
+		{Id: "b", Lat: -91, Lon: 0},
+		{Id: "c", Lat: 41.9028, Lon: 12.4964},
+	}
+	for _, req := range requests {
+		assert.NoError(t, stream.Send(req))
+	}
+	assert.NoError(t, stream.CloseSend())
+	for _, req := range requests {
+		resp, err := stream.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, req.GetId(), resp.GetId())
+	}
+	_, err = stream.Recv()
+	assert.Error(t, err)
+	unauthorized, err := client.StreamLookup(ctx)
+	assert.NoError(t, err)
+	_, err = unauthorized.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// the calls are counted with the REST requests
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	server.echo.ServeHTTP(rec, req)
+	assert.Contains(t, rec.Body.String(), `geo2tz_http_requests_total{client="alice",method="GRPC",route="/geo2tz.v1.Geo2Tz/Lookup",status="OK"} 1`)
+	assert.Contains(t, rec.Body.String(), `geo2tz_http_requests_total{client="anonymous",method="GRPC",route="/geo2tz.v1.Geo2Tz/Version",status="Unauthenticated"} 2`)
+}
+
+func TestServer_GRPCRateLimit(t *testing.T) {
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  "../tzdata/version.json",
+			DatabaseName: "../db/testdata/timezones.zip",
+		},
+		Web: WebSchema{
+			RateLimit:         0.001,
+			RateLimitBurst:    3,
+			GRPCListenAddress: "bufconn",
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+	client := newGRPCTestClient(t, server)
+	ctx := context.Background()
+
+	// batches count the coordinates
+	_, err = client.BatchLookup(ctx, &api.BatchLookupRequest{Items: make([]*api.LookupRequest, 4)})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	_, err = client.BatchLookup(ctx, &api.BatchLookupRequest{Items: []*api.LookupRequest{
+		{Lat: 41.9028, Lon: 12.4964},
+		{Lat: 41.9028, Lon: 12.4964},
+	}})
+	assert.NoError(t, err)
+
+	// streams count each of the coordinates
+	stream, err := client.StreamLookup(ctx)
+	assert.NoError(t, err)
+	for range 2 {
+		assert.NoError(t, stream.Send(&api.LookupRequest{Lat: 41.9028, Lon: 12.4964}))
+	}
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Rome", resp.GetTz())
+	resp, err = stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(codes.ResourceExhausted), resp.GetError().GetCode())
+	assert.NoError(t, stream.CloseSend())
+
+	_, err = client.Lookup(ctx, &api.LookupRequest{Lat: 41.9028, Lon: 12.4964})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestServer_StartGRPC(t *testing.T) {
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  "../tzdata/version.json",
+			DatabaseName: "../db/testdata/timezones.zip",
+		},
+		Web: WebSchema{
+			ListenAddress:     "127.0.0.1:0",
+			GRPCListenAddress: "127.0.0.1:0",
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+	started := make(chan error, 1)
+	go func() { started <- server.Start() }()
+	assert.NoError(t, server.Teardown())
+	assert.NoError(t, <-started)
+
+	// the server does not start if the gRPC address is not available
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer lis.Close()
+	settings.Web.GRPCListenAddress = lis.Addr().String()
+	server, err = NewServer(settings)
+	assert.NoError(t, err)
+	assert.Error(t, server.Start())
+}
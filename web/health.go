@@ -0,0 +1,33 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+)
+
+// the status values reported by the health endpoints
+const (
+	StatusOK       = "ok"
+	StatusReady    = "ready"
+	StatusNotReady = "not ready"
+)
+
+// Ready reports whether the server is ready to serve the lookups,
+// it is false until the database is loaded, while it is reloaded and once the shutdown starts
+func (server *Server) Ready() bool {
+	return server.ready.Load() && !server.draining.Load()
+}
+
+// handleHealth reports that the server is running
+func (server *Server) handleHealth(c *echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]any{"status": StatusOK})
+}
+
+// handleReady reports whether the server is ready to serve the lookups
+func (server *Server) handleReady(c *echo.Context) error {
+	if !server.Ready() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]any{"status": StatusNotReady})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"status": StatusReady, "version": server.Release().Version})
+}
@@ -0,0 +1,135 @@
+package web
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_Health(t *testing.T) {
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  "../tzdata/version.json",
+			DatabaseName: "../db/testdata/timezones.zip",
+		},
+		Web: WebSchema{
+			AuthTokenValue:     "secret",
+			AuthTokenParamName: "t",
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+	assert.True(t, server.Ready())
+
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rec := httptest.NewRecorder()
+		server.echo.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// the health endpoints do not require the token
+	rec := get("/healthz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"status":"ok"}`, strings.TrimSpace(rec.Body.String()))
+
+	rec = get("/readyz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"ready"`)
+	assert.Contains(t, rec.Body.String(), `"version":"`+server.Release().Version+`"`)
+
+	// while the database is reloaded the server is alive but not ready
+	server.ready.Store(false)
+	rec = get("/healthz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, `{"status":"not ready"}`, strings.TrimSpace(rec.Body.String()))
+
+	// and ready again once reloaded
+	_, err = server.Reload()
+	assert.NoError(t, err)
+	assert.True(t, server.Ready())
+	rec = get("/readyz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestServer_Teardown(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		wantErr error
+	}{
+		{"drained", 2 * time.Second, nil},
+		{"timeout", 100 * time.Millisecond, ErrorShutdownTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// reserve a free port
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.NoError(t, err)
+			address := l.Addr().String()
+			assert.NoError(t, l.Close())
+
+			settings := ConfigSchema{
+				Tz: TzSchema{
+					VersionFile:  "../tzdata/version.json",
+					DatabaseName: "../db/testdata/timezones.zip",
+				},
+				Web: WebSchema{
+					ListenAddress:   address,
+					ShutdownTimeout: tt.timeout,
+				},
+			}
+			server, err := NewServer(settings)
+			assert.NoError(t, err)
+			// a request that is in-flight when the shutdown starts
+			started := make(chan struct{})
+			server.echo.GET("/slow", func(c *echo.Context) error {
+				close(started)
+				time.Sleep(500 * time.Millisecond)
+				return c.String(http.StatusOK, "done")
+			})
+			go func() {
+				assert.NoError(t, server.Start())
+			}()
+
+			// wait for the server to listen
+			assert.Eventually(t, func() bool {
+				rsp, err := http.Get("http://" + address + "/healthz")
+				if err != nil {
+					return false
+				}
+				return rsp.Body.Close() == nil
+			}, 5*time.Second, 10*time.Millisecond)
+
+			code := make(chan int, 1)
+			go func() {
+				rsp, err := http.Get("http://" + address + "/slow")
+				if err != nil {
+					code <- 0
+					return
+				}
+				_ = rsp.Body.Close()
+				code <- rsp.StatusCode
+			}()
+			<-started
+
+			assert.True(t, server.Ready())
+			err = server.Teardown()
+			assert.False(t, server.Ready())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, <-code)
+		})
+	}
+}
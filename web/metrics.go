@@ -0,0 +1,157 @@
+package web
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/noandrea/geo2tz/v2/db"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// metricsNamespace is the prefix of the metrics names
+	metricsNamespace = "geo2tz"
+	// anonymousClient is the client label of the requests without authorization
+	anonymousClient = "anonymous"
+)
+
+// metrics are the Prometheus metrics of the server,
+// a nil metrics ignores all the observations so that they can be disabled
+type metrics struct {
+	registry             *prometheus.Registry
+	requests             *prometheus.CounterVec
+	lookupDuration       prometheus.Histogram
+	lookups              *prometheus.CounterVec
+	notFound             prometheus.Counter
+	polygonsTested       prometheus.Histogram
+	databaseInfo         *prometheus.GaugeVec
+	databaseLoadDuration prometheus.Gauge
+	databaseLoadTime     prometheus.Gauge
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route, method, status code and authenticated client.",
+		}, []string{"route", "method", "status", "client"}),
+		lookupDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "lookup_duration_seconds",
+			Help:      "Duration of the timezone lookups.",
+			Buckets:   prometheus.ExponentialBuckets(0.000005, 4, 10), // 5µs to ~1.3s
+		}),
+		lookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "lookups_total",
+			Help:      "Number of timezones found by source (override, land, sea, fallback, nautical).",
+		}, []string{"source"}),
+		notFound: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "lookups_not_found_total",
+			Help:      "Number of lookups that did not find a timezone.",
+		}),
+		polygonsTested: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "lookup_polygons_tested",
+			Help:      "Number of polygons tested for containment by each lookup.",
+			Buckets:   []float64{0, 1, 2, 3, 5, 10, 20, 50, 100},
+		}),
+		databaseInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "database_info",
+			Help:      "Release of the timezone database in use, the value is always 1.",
+		}, []string{"version", "variant"}),
+		databaseLoadDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "database_load_duration_seconds",
+			Help:      "Time taken to load the timezone database in use.",
+		}),
+		databaseLoadTime: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "database_load_timestamp_seconds",
+			Help:      "Unix time when the timezone database in use was loaded.",
+		}),
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.lookupDuration,
+		m.lookups,
+		m.notFound,
+		m.polygonsTested,
+		m.databaseInfo,
+		m.databaseLoadDuration,
+		m.databaseLoadTime,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// middleware counts the requests by route, status code and client
+func (m *metrics) middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c *echo.Context) error {
+		err := next(c)
+		_, status := echo.ResolveResponseStatus(c.Response(), err)
+		// unknown paths are grouped to keep the number of series bounded
+		route := c.Path()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.observeRequest(route, c.Request().Method, strconv.Itoa(status), requestClient(c))
+		return err
+	}
+}
+
+// observeRequest counts a request
+func (m *metrics) observeRequest(route, method, status, client string) {
+	if m == nil {
+		return
+	}
+	if client == "" {
+		client = anonymousClient
+	}
+	m.requests.WithLabelValues(route, method, status, client).Inc()
+}
+
+// handler serves the metrics in the Prometheus format
+func (m *metrics) handler() echo.HandlerFunc {
+	return echo.WrapHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// observeLookup records the stats of a lookup
+func (m *metrics) observeLookup(stats db.LookupStats) {
+	if m == nil {
+		return
+	}
+	if stats.Source == "" {
+		m.notFound.Inc()
+	} else {
+		m.lookups.WithLabelValues(stats.Source).Inc()
+	}
+	m.polygonsTested.Observe(float64(stats.Tested))
+}
+
+// observeLookupDuration records the duration of a lookup started at start
+func (m *metrics) observeLookupDuration(start time.Time) {
+	if m == nil {
+		return
+	}
+	m.lookupDuration.Observe(time.Since(start).Seconds())
+}
+
+// observeDataset records the release and the load time of the database in use
+func (m *metrics) observeDataset(ds *dataset) {
+	if m == nil {
+		return
+	}
+	m.databaseInfo.Reset()
+	m.databaseInfo.WithLabelValues(ds.tzRelease.Version, ds.tzRelease.Variant).Set(1)
+	m.databaseLoadDuration.Set(ds.loadDuration.Seconds())
+	m.databaseLoadTime.Set(float64(ds.loadedAt.Unix()))
+}
@@ -0,0 +1,61 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_Metrics(t *testing.T) {
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  "../tzdata/version.json",
+			DatabaseName: "../db/testdata/timezones.zip",
+		},
+	}
+
+	// the endpoint is available only when enabled
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	server.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	settings.Web.MetricsEnabled = true
+	server, err = NewServer(settings)
+	assert.NoError(t, err)
+	for _, target := range []string{
+		"/tz/41.9028/12.4964", // Rome
+		"/tz/52.52/13.405",    // Berlin
+		"/tz/30/-40",          // Atlantic ocean, not found
+		"/tz/91/0",            // invalid
+		"/not/a/route",
+	} {
+		req = httptest.NewRequest(http.MethodGet, target, nil)
+		server.echo.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec = httptest.NewRecorder()
+	server.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	for _, want := range []string{
+		`geo2tz_http_requests_total{client="anonymous",method="GET",route="/tz/:lat/:lon",status="200"} 2`,
+		`geo2tz_http_requests_total{client="anonymous",method="GET",route="/tz/:lat/:lon",status="404"} 1`,
+		`geo2tz_http_requests_total{client="anonymous",method="GET",route="/tz/:lat/:lon",status="400"} 1`,
+		`geo2tz_http_requests_total{client="anonymous",method="GET",route="unmatched",status="404"} 1`,
+		`geo2tz_lookups_total{source="land"} 2`,
+		`geo2tz_lookups_not_found_total 1`,
+		`geo2tz_lookup_duration_seconds_count 3`,
+		`geo2tz_lookup_polygons_tested_count 3`,
+		`geo2tz_database_info{variant="",version="`,
+		`geo2tz_database_load_duration_seconds `,
+		`geo2tz_database_load_timestamp_seconds `,
+	} {
+		assert.Contains(t, body, want)
+	}
+}
@@ -0,0 +1,113 @@
+package web
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v5"
+	"github.com/labstack/echo/v5/middleware"
+)
+
+// Redacted replaces the sensitive values in the logs
+const Redacted = "redacted"
+
+// decimalPattern matches the decimal numbers, that are coarsened as coordinates in the log messages
+var decimalPattern = regexp.MustCompile(`-?\d+\.\d+`)
+
+// redactCoordinate formats a coordinate for the logs, with the privacy enabled
+// it is rounded to the configured precision or redacted if the precision is negative
+func (server *Server) redactCoordinate(v float64) string {
+	if !server.config.Web.Privacy {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	if server.config.Web.PrivacyPrecision < 0 {
+		return Redacted
+	}
+	return strconv.FormatFloat(v, 'f', server.config.Web.PrivacyPrecision, 64)
+}
+
+// redactText coarsens the decimal numbers in a log message, with the privacy enabled
+func (server *Server) redactText(s string) string {
+	if !server.config.Web.Privacy {
+		return s
+	}
+	return decimalPattern.ReplaceAllStringFunc(s, func(v string) string {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Redacted
+		}
+		return server.redactCoordinate(f)
+	})
+}
+
+// redactURI coarsens the coordinates in the path of a request URI and removes the token
+// from the query, with the privacy enabled
+func (server *Server) redactURI(uri string) string {
+	if !server.config.Web.Privacy {
+		return uri
+	}
+	u, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return Redacted
+	}
+	segments := strings.Split(u.Path, "/")
+	for i, s := range segments {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			segments[i] = server.redactCoordinate(f)
+		}
+	}
+	u.Path, u.RawPath = strings.Join(segments, "/"), ""
+	if name := server.config.Web.AuthTokenParamName; name != "" && u.Query().Has(name) {
+		q := u.Query()
+		q.Set(name, Redacted)
+		u.RawQuery = q.Encode()
+	}
+	return u.RequestURI()
+}
+
+// logError logs an error, coarsening the coordinates in the message with the privacy enabled
+func (server *Server) logError(msg string, err error) {
+	server.echo.Logger.Error(msg, "error", server.redactText(err.Error()))
+}
+
+// requestLogger logs the requests as middleware.RequestLogger does, redacting the URI
+func (server *Server) requestLogger() echo.MiddlewareFunc {
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		LogLatency:       true,
+		LogRemoteIP:      true,
+		LogHost:          true,
+		LogMethod:        true,
+		LogURI:           true,
+		LogRequestID:     true,
+		LogUserAgent:     true,
+		LogStatus:        true,
+		LogContentLength: true,
+		LogResponseSize:  true,
+		HandleError:      true,
+		LogValuesFunc: func(c *echo.Context, v middleware.RequestLoggerValues) error {
+			attrs := []slog.Attr{
+				slog.String("method", v.Method),
+				slog.String("uri", server.redactURI(v.URI)),
+				slog.Int("status", v.Status),
+				slog.Duration("latency", v.Latency),
+				slog.String("host", v.Host),
+				slog.String("bytes_in", v.ContentLength),
+				slog.Int64("bytes_out", v.ResponseSize),
+				slog.String("user_agent", v.UserAgent),
+				slog.String("remote_ip", v.RemoteIP),
+				slog.String("request_id", v.RequestID),
+			}
+			if v.Error == nil {
+				c.Logger().LogAttrs(context.Background(), slog.LevelInfo, "REQUEST", attrs...)
+				return nil
+			}
+			attrs = append(attrs, slog.String("error", server.redactText(v.Error.Error())))
+			c.Logger().LogAttrs(context.Background(), slog.LevelError, "REQUEST_ERROR", attrs...)
+			return nil
+		},
+	})
+}
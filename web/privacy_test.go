@@ -0,0 +1,87 @@
+package web
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_redact(t *testing.T) {
+	tests := []struct {
+		name      string
+		privacy   bool
+		precision int
+		wantCoord string
+		wantText  string
+		wantURI   string
+	}{
+		{"disabled", false, 0, "41.902782", "not found for coordinates 41.902782,-12.496365", "/tz/41.902782/-12.496365?all=true&t=secret"},
+		{"precision 2", true, 2, "41.90", "not found for coordinates 41.90,-12.50", "/tz/41.90/-12.50?all=true&t=redacted"},
+		{"precision 0", true, 0, "42", "not found for coordinates 42,-12", "/tz/42/-12?all=true&t=redacted"},
+		{"redacted", true, -1, "redacted", "not found for coordinates redacted,redacted", "/tz/redacted/redacted?all=true&t=redacted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &Server{config: ConfigSchema{Web: WebSchema{
+				AuthTokenParamName: "t",
+				Privacy:            tt.privacy,
+				PrivacyPrecision:   tt.precision,
+			}}}
+			assert.Equal(t, tt.wantCoord, server.redactCoordinate(41.902782))
+			assert.Equal(t, tt.wantText, server.redactText("not found for coordinates 41.902782,-12.496365"))
+			assert.Equal(t, tt.wantURI, server.redactURI("/tz/41.902782/-12.496365?all=true&t=secret"))
+		})
+	}
+
+	server := &Server{config: ConfigSchema{Web: WebSchema{Privacy: true, PrivacyPrecision: 1}}}
+	// integer coordinates are coarsened too, other paths are untouched
+	assert.Equal(t, "/tz/45.0/9.0", server.redactURI("/tz/45/9"))
+	assert.Equal(t, "/tz/version", server.redactURI("/tz/version"))
+	assert.Equal(t, "/tz/41.9/12.5?at=1720958400", server.redactURI("/tz/41.902782/12.496365?at=1720958400"))
+	// without a token parameter the query is kept
+	assert.Equal(t, "/tz/41.9/12.5?t=secret", server.redactURI("/tz/41.902782/12.496365?t=secret"))
+}
+
+func TestServer_PrivacyLogs(t *testing.T) {
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  "../tzdata/version.json",
+			DatabaseName: "../db/testdata/timezones.zip",
+		},
+		Web: WebSchema{
+			AuthTokenValue:     "secret",
+			AuthTokenParamName: "t",
+			Privacy:            true,
+			PrivacyPrecision:   1,
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+	var logs bytes.Buffer
+	server.echo.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	for _, target := range []string{
+		"/tz/41.902782/12.496365?t=secret",  // Rome
+		"/tz/30.123456/-40.654321?t=secret", // Atlantic ocean, not found
+		"/tz/91.123456/12.496365?t=secret",  // invalid
+		"/tz/41.902782/12.496365?t=s3cret",  // unauthorized
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		server.echo.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/tz/41.902782/12.496365", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	server.echo.ServeHTTP(httptest.NewRecorder(), req)
+
+	out := logs.String()
+	for _, secret := range []string{"902782", "496365", "123456", "654321", "secret", "s3cret"} {
+		assert.NotContains(t, out, secret)
+	}
+	assert.Contains(t, out, `"uri":"/tz/41.9/12.5?t=redacted"`)
+	assert.Contains(t, out, "timezone not found for coordinates 30.1,-40.7")
+	assert.Contains(t, out, "lat value 91.1 out of range")
+}
@@ -0,0 +1,206 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitSweepInterval is how often the idle clients are removed from the rate limiter
+const rateLimitSweepInterval = time.Minute
+
+// rateLimiter limits the rate and the daily quota of the coordinates queried by each client
+type rateLimiter struct {
+	limit rate.Limit
+	burst int
+	quota int
+	now   func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*clientLimit
+	lastSweep time.Time
+}
+
+// clientLimit is the state of the limits of a client
+type clientLimit struct {
+	limiter *rate.Limiter
+	// day is the start of the UTC day of the quota usage
+	day  time.Time
+	used int
+}
+
+// newRateLimiter returns a rate limiter, or nil if neither the rate nor the quota are limited
+func newRateLimiter(config WebSchema) *rateLimiter {
+	if config.RateLimit <= 0 && config.DailyQuota <= 0 {
+		return nil
+	}
+	l := &rateLimiter{
+		limit:   rate.Inf,
+		quota:   config.DailyQuota,
+		now:     time.Now,
+		clients: map[string]*clientLimit{},
+	}
+	if config.RateLimit > 0 {
+		l.limit = rate.Limit(config.RateLimit)
+		// by default the burst allows one second worth of requests, and at least
+		// a full batch, otherwise the larger batches would never be allowed
+		l.burst = config.RateLimitBurst
+		if l.burst <= 0 {
+			l.burst = max(int(math.Ceil(config.RateLimit)), config.BatchMaxSize)
+		}
+	}
+	return l
+}
+
+// allow checks if the client can query n coordinates, if not it returns
+// how long to wait before retrying, that is 0 if n exceeds the burst or the daily quota
+func (l *rateLimiter) allow(key string, n int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	cl, ok := l.clients[key]
+	if !ok {
+		cl = &clientLimit{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = cl
+	}
+
+	// the quota is reset at midnight UTC
+	day := now.UTC().Truncate(24 * time.Hour)
+	if !cl.day.Equal(day) {
+		cl.day, cl.used = day, 0
+	}
+	if l.quota > 0 && n > l.quota {
+		return false, 0
+	}
+	if l.quota > 0 && cl.used+n > l.quota {
+		return false, day.Add(24 * time.Hour).Sub(now)
+	}
+
+	r := cl.limiter.ReserveN(now, n)
+	if !r.OK() {
+		return false, 0
+	}
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	cl.used += n
+	return true, 0
+}
+
+// quotaExceeded checks if n coordinates exceed the daily quota left to the client
+func (l *rateLimiter) quotaExceeded(key string, n int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.quota <= 0 {
+		return false
+	}
+	used := 0
+	if cl, ok := l.clients[key]; ok && cl.day.Equal(l.now().UTC().Truncate(24*time.Hour)) {
+		used = cl.used
+	}
+	return used+n > l.quota
+}
+
+// sweep removes the clients that are back to their full burst and have no quota usage for the day
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	day := now.UTC().Truncate(24 * time.Hour)
+	for key, cl := range l.clients {
+		if (l.limit == rate.Inf || cl.limiter.TokensAt(now) >= float64(l.burst)) && (l.quota <= 0 || !cl.day.Equal(day)) {
+			delete(l.clients, key)
+		}
+	}
+}
+
+// rateLimit checks if the request can query n coordinates, the limits are applied
+// to the authenticated client or, without authorization, to the client IP
+// it returns http.StatusOK if the request is allowed, or the status and the reply otherwise
+func (server *Server) rateLimit(c *echo.Context, n int) (int, map[string]any) {
+	retryAfter, err := server.checkRateLimit(rateLimitKey(requestClient(c), c.RealIP()), n)
+	switch {
+	case err == nil:
+		return http.StatusOK, nil
+	case retryAfter == 0:
+		return http.StatusRequestEntityTooLarge, newErrResponse(err)
+	default:
+		c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		return http.StatusTooManyRequests, newErrResponse(err)
+	}
+}
+
+// rateLimitKey returns the key of the limits of the authenticated client or, if there is none, of the client IP
+func rateLimitKey(client, ip string) string {
+	if client != "" {
+		return "client:" + client
+	}
+	return "ip:" + ip
+}
+
+// checkRateLimit returns an error if n coordinates exceed the limits of the client with the key,
+// together with the seconds to wait before retrying, that are 0 if they will never be allowed
+func (server *Server) checkRateLimit(key string, n int) (int, error) {
+	if server.rateLimiter == nil {
+		return 0, nil
+	}
+	ok, retryAfter := server.rateLimiter.allow(key, n)
+	if ok {
+		return 0, nil
+	}
+	return server.rateLimitError(n, retryAfter)
+}
+
+// waitRateLimit waits until the client with the key can query n coordinates, or the context
+// is done, so that the streams are slowed down instead of rejected; it fails without waiting
+// if n coordinates exceed the burst or the daily quota left, that are not refilled in time
+func (server *Server) waitRateLimit(ctx context.Context, key string, n int) error {
+	if server.rateLimiter == nil {
+		return nil
+	}
+	for {
+		ok, delay := server.rateLimiter.allow(key, n)
+		if ok {
+			return nil
+		}
+		if delay == 0 {
+			_, err := server.rateLimitError(n, delay)
+			return err
+		}
+		if server.rateLimiter.quotaExceeded(key, n) {
+			return fmt.Errorf("daily quota of %d coordinates exceeded", server.rateLimiter.quota)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// rateLimitError returns the error of n coordinates exceeding the limits, together with
+// the seconds to wait before retrying, that are 0 if they will never be allowed
+func (server *Server) rateLimitError(n int, retryAfter time.Duration) (int, error) {
+	if retryAfter == 0 && server.rateLimiter.quota > 0 && n > server.rateLimiter.quota {
+		return 0, fmt.Errorf("request of %d coordinates exceeds the daily quota of %d", n, server.rateLimiter.quota)
+	}
+	if retryAfter == 0 {
+		return 0, fmt.Errorf("request of %d coordinates exceeds the rate limit burst of %d", n, server.rateLimiter.burst)
+	}
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return seconds, fmt.Errorf("rate limit exceeded, retry in %d seconds", seconds)
+}
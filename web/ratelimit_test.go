@@ -0,0 +1,157 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newRateLimiter(t *testing.T) {
+	assert.Nil(t, newRateLimiter(WebSchema{}))
+
+	l := newRateLimiter(WebSchema{RateLimit: 2.5})
+	assert.Equal(t, 3, l.burst)
+	l = newRateLimiter(WebSchema{RateLimit: 2.5, RateLimitBurst: 10})
+	assert.Equal(t, 10, l.burst)
+	// the default burst allows a full batch
+	l = newRateLimiter(WebSchema{RateLimit: 2, BatchMaxSize: 1000})
+	assert.Equal(t, 1000, l.burst)
+	ok, _ := l.allow("a", 1000)
+	assert.True(t, ok)
+	l = newRateLimiter(WebSchema{RateLimit: 2, RateLimitBurst: 10, BatchMaxSize: 1000})
+	assert.Equal(t, 10, l.burst)
+	l = newRateLimiter(WebSchema{DailyQuota: 100})
+	assert.Equal(t, 100, l.quota)
+}
+
+func Test_rateLimiter_allow(t *testing.T) {
+	now := time.Date(2024, 7, 15, 23, 59, 0, 0, time.UTC)
+	l := newRateLimiter(WebSchema{RateLimit: 10, RateLimitBurst: 5, DailyQuota: 12})
+	l.now = func() time.Time { return now }
+
+	// the burst is available at once
+	for range 5 {
+		ok, _ := l.allow("a", 1)
+		assert.True(t, ok)
+	}
+	ok, retryAfter := l.allow("a", 1)
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+	// each client has its own limits
+	ok, _ = l.allow("b", 5)
+	assert.True(t, ok)
+	// requests larger than the burst are never allowed
+	ok, retryAfter = l.allow("c", 6)
+	assert.False(t, ok)
+	assert.Zero(t, retryAfter)
+
+	// the tokens are refilled over time
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.allow("a", 5)
+	assert.True(t, ok)
+
+	// until the daily quota is used
+	now = now.Add(time.Second)
+	ok, retryAfter = l.allow("a", 3)
+	assert.False(t, ok)
+	assert.Equal(t, 58500*time.Millisecond, retryAfter)
+	ok, _ = l.allow("a", 2)
+	assert.True(t, ok)
+
+	// the quota is reset at midnight UTC
+	now = now.Add(time.Minute)
+	ok, _ = l.allow("a", 5)
+	assert.True(t, ok)
+
+	// idle clients are removed
+	now = now.Add(25 * time.Hour)
+	_, _ = l.allow("a", 1)
+	assert.Len(t, l.clients, 1)
+
+	// requests larger than the daily quota are never allowed
+	l = newRateLimiter(WebSchema{RateLimit: 10, RateLimitBurst: 20, DailyQuota: 12})
+	l.now = func() time.Time { return now }
+	ok, retryAfter = l.allow("a", 13)
+	assert.False(t, ok)
+	assert.Zero(t, retryAfter)
+	ok, _ = l.allow("a", 12)
+	assert.True(t, ok)
+}
+
+func TestServer_rateLimit(t *testing.T) {
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  "../tzdata/version.json",
+			DatabaseName: "../db/testdata/timezones.zip",
+		},
+		Web: WebSchema{
+			AuthTokens:     map[string]string{"alice": "al1ce", "bob": "b0b"},
+			BatchMaxSize:   10,
+			RateLimit:      0.001,
+			RateLimitBurst: 3,
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+
+	do := func(token, target, body string) *httptest.ResponseRecorder {
+		method := http.MethodGet
+		if body != "" {
+			method = http.MethodPost
+		}
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		server.echo.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// batches count the coordinates
+	rec := do("al1ce", "/tz/batch", `[{"lat":41.9028,"lon":12.4964},{"lat":52.52,"lon":13.405}]`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = do("al1ce", "/tz/41.9028/12.4964", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = do("al1ce", "/tz/41.9028/12.4964", "")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	// the limits are per client
+	rec = do("b0b", "/tz/41.9028/12.4964", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = do("b0b", "/tz/batch", `[{"lat":1,"lon":1},{"lat":2,"lon":2},{"lat":3,"lon":3},{"lat":4,"lon":4}]`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Empty(t, rec.Header().Get("Retry-After"))
+
+	// unauthorized requests are rejected before being counted
+	rec = do("nope", "/tz/41.9028/12.4964", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestServer_waitRateLimit(t *testing.T) {
+	server := &Server{rateLimiter: newRateLimiter(WebSchema{RateLimit: 20, RateLimitBurst: 1, DailyQuota: 3})}
+	ctx := context.Background()
+
+	// the coordinates over the burst wait for the rate limit
+	start := time.Now()
+	assert.NoError(t, server.waitRateLimit(ctx, "a", 1))
+	assert.NoError(t, server.waitRateLimit(ctx, "a", 1))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	// the wait is bounded by the context
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, server.waitRateLimit(canceled, "a", 1), context.Canceled)
+
+	// the burst and the daily quota fail at once
+	assert.EqualError(t, server.waitRateLimit(ctx, "b", 2), "request of 2 coordinates exceeds the rate limit burst of 1")
+	assert.NoError(t, server.waitRateLimit(ctx, "a", 1))
+	assert.EqualError(t, server.waitRateLimit(ctx, "a", 1), "daily quota of 3 coordinates exceeded")
+
+	// without limits there is no wait
+	assert.NoError(t, (&Server{}).waitRateLimit(canceled, "a", 1))
+}
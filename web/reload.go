@@ -0,0 +1,121 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/noandrea/geo2tz/v2/db"
+	"github.com/noandrea/geo2tz/v2/helpers"
+)
+
+// dataset is the timezone database together with its release info,
+// they are always replaced together so the version matches the data served
+type dataset struct {
+	tzDB         db.TzDBIndex
+	tzRelease    TzRelease
+	loadedAt     time.Time
+	loadDuration time.Duration
+}
+
+// loadDataset loads the timezone database and the release info,
+// the lookups of the database are recorded in the metrics, if enabled
+func loadDataset(config TzSchema, m *metrics) (*dataset, error) {
+	ds := dataset{loadedAt: time.Now()}
+	// load the database
+	tzDB, err := LoadDatabase(config)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		tzDB.SetLookupObserver(m.observeLookup)
+	}
+	ds.tzDB = tzDB
+
+	// load the release info
+	if err = helpers.LoadJSON(config.VersionFile, &ds.tzRelease); err != nil {
+		err = errors.Join(ErrorVersionFileNotFound, err, fmt.Errorf("error loading the timezone release info: %w", err))
+		return nil, err
+	}
+	ds.loadDuration = time.Since(ds.loadedAt)
+	return &ds, nil
+}
+
+// LoadDatabase loads the timezone database with the configured fallbacks and overrides
+func LoadDatabase(config TzSchema) (*db.Geo2TzRTreeIndex, error) {
+	tzDB, err := db.NewGeo2TzRTreeIndex(config.DatabaseName)
+	if err != nil {
+		return nil, errors.Join(ErrorDatabaseFileNotFound, err)
+	}
+	tzDB.SetFallbackMaxDistance(config.FallbackMaxDistance)
+	// the land-only datasets have no zones at sea, the points there get the nautical zones
+	tzDB.SetNauticalFallback(config.NauticalFallback || (isLandVariant(config.Variant) && !tzDB.HasOceans()))
+	// load the custom zones layered on top of the dataset
+	if config.OverridesFile != "" {
+		if err = tzDB.LoadOverridesFile(config.OverridesFile); err != nil {
+			return nil, errors.Join(ErrorOverridesFile, fmt.Errorf("error loading the overrides file %s: %w", config.OverridesFile, err))
+		}
+	}
+	return tzDB, nil
+}
+
+// Reload loads the timezone database and the release info from the configured files
+// and replaces the ones in use, the current database keeps serving the requests until
+// the new one is fully loaded, if the loading fails the current database is kept
+func (server *Server) Reload() (TzRelease, error) {
+	// prevent concurrent reloads from loading the database multiple times
+	server.reloadMu.Lock()
+	defer server.reloadMu.Unlock()
+	// the current database keeps serving, but the server is reported as not ready
+	// until the reload is completed
+	server.ready.Store(false)
+	defer server.ready.Store(true)
+
+	ds, err := loadDataset(server.config.Tz, server.metrics)
+	if err != nil {
+		server.logError("error reloading the timezone database", err)
+		return server.dataset.Load().tzRelease, err
+	}
+	server.dataset.Store(ds)
+	server.metrics.observeDataset(ds)
+	server.echo.Logger.Info("timezone database reloaded", "version", ds.tzRelease.Version)
+	return ds.tzRelease, nil
+}
+
+// Release returns the release info of the timezone database in use
+func (server *Server) Release() TzRelease {
+	return server.dataset.Load().tzRelease
+}
+
+// UpdateStatus reports the state of the background updates of the timezone database
+type UpdateStatus struct {
+	Interval      string    `json:"interval"`
+	LastCheck     time.Time `json:"last_check,omitzero"`
+	LastUpdate    time.Time `json:"last_update,omitzero"`
+	LatestVersion string    `json:"latest_version,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+}
+
+// SetUpdateStatus sets the state of the background updates reported with the version
+func (server *Server) SetUpdateStatus(status UpdateStatus) {
+	server.updateStatus.Store(&status)
+}
+
+func (server *Server) handleReload(c *echo.Context) error {
+	// token verification
+	if !server.isAuthorized(c) {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"message": "unauthorized"})
+	}
+	// a reload takes seconds and memory, only the admin clients can trigger it
+	if !server.isAdmin(requestClient(c)) {
+		server.echo.Logger.Error("request forbidden, not an admin client", clientKey, requestClient(c))
+		return c.JSON(http.StatusForbidden, map[string]interface{}{"message": "forbidden"})
+	}
+	release, err := server.Reload()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, newErrResponse(err))
+	}
+	return c.JSON(http.StatusOK, release)
+}
@@ -0,0 +1,185 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/noandrea/geo2tz/v2/db"
+	"github.com/noandrea/geo2tz/v2/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+// copyFile copies a file for tests
+func copyFile(t *testing.T, src, dst string) {
+	data, err := os.ReadFile(src)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(dst, data, 0o600))
+}
+
+func TestServer_Reload(t *testing.T) {
+	dir := t.TempDir()
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  filepath.Join(dir, "version.json"),
+			DatabaseName: filepath.Join(dir, "timezones.zip"),
+		},
+		Web: WebSchema{
+			AuthTokenValue:     "secret",
+			AuthTokenParamName: "t",
+			AuthTokens:         map[string]string{"alice": "al1ce", "ops": "0ps"},
+			AdminClients:       []string{DefaultTokenName, "Ops"},
+		},
+	}
+	copyFile(t, "../db/testdata/timezones.zip", settings.Tz.DatabaseName)
+	assert.NoError(t, helpers.SaveJSON(NewTzRelease("2024a"), settings.Tz.VersionFile))
+
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024a", server.dataset.Load().tzRelease.Version)
+
+	// a new release is picked up on reload
+	assert.NoError(t, helpers.SaveJSON(NewTzRelease("2024b"), settings.Tz.VersionFile))
+	release, err := server.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, "2024b", release.Version)
+	assert.Equal(t, "2024b", server.dataset.Load().tzRelease.Version)
+
+	// a broken database keeps the current one
+	current := server.dataset.Load()
+	assert.NoError(t, os.WriteFile(settings.Tz.DatabaseName, []byte("broken"), 0o600))
+	assert.NoError(t, helpers.SaveJSON(NewTzRelease("2024c"), settings.Tz.VersionFile))
+	release, err = server.Reload()
+	assert.ErrorIs(t, err, ErrorDatabaseFileNotFound)
+	assert.Equal(t, "2024b", release.Version)
+	assert.Same(t, current, server.dataset.Load())
+
+	// the admin endpoint requires the token
+	copyFile(t, "../db/testdata/timezones.zip", settings.Tz.DatabaseName)
+	req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+	rec := httptest.NewRecorder()
+	server.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// and the client to be an admin
+	req = httptest.NewRequest(http.MethodPost, "/admin/reload?t=al1ce", nil)
+	rec = httptest.NewRecorder()
+	server.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/reload?t=0ps", nil)
+	rec = httptest.NewRecorder()
+	server.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/reload?t=secret", nil)
+	rec = httptest.NewRecorder()
+	server.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &release))
+	assert.Equal(t, "2024c", release.Version)
+
+	// and the version endpoint reports the new release
+	req = httptest.NewRequest(http.MethodGet, "/tz/version", nil)
+	rec = httptest.NewRecorder()
+	server.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &release))
+	assert.Equal(t, "2024c", release.Version)
+}
+
+func TestServer_SetUpdateStatus(t *testing.T) {
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  "../tzdata/version.json",
+			DatabaseName: "../db/testdata/timezones.zip",
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+
+	version := func() map[string]any {
+		req := httptest.NewRequest(http.MethodGet, "/tz/version", nil)
+		rec := httptest.NewRecorder()
+		server.echo.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var reply map[string]any
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+		return reply
+	}
+
+	// the status is not reported when the background updates are disabled
+	reply := version()
+	assert.NotEmpty(t, reply["version"])
+	assert.NotContains(t, reply, "auto_update")
+
+	server.SetUpdateStatus(UpdateStatus{
+		Interval:      "24h0m0s",
+		LastCheck:     time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+		LatestVersion: "2024b",
+		LastError:     "connection refused",
+	})
+	reply = version()
+	assert.NotEmpty(t, reply["version"])
+	assert.Equal(t, map[string]any{
+		"interval":       "24h0m0s",
+		"last_check":     "2024-01-15T12:00:00Z",
+		"latest_version": "2024b",
+		"last_error":     "connection refused",
+	}, reply["auto_update"])
+}
+
+func TestServer_handleReloadNoAdmins(t *testing.T) {
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  "../tzdata/version.json",
+			DatabaseName: "../db/testdata/timezones.zip",
+		},
+		Web: WebSchema{
+			AuthTokenValue: "secret",
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+
+	// without admin clients the admin routes are not available
+	req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	server.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestLoadDatabase(t *testing.T) {
+	// the test dataset has no ocean zones
+	tests := []struct {
+		name     string
+		variant  string
+		nautical bool
+		want     db.Result
+		wantErr  error
+	}{
+		{"land variant", VariantLand, false, db.Result{TzID: "Etc/GMT+3", Nautical: true}, nil},
+		{"land now variant", VariantLandNow, false, db.Result{TzID: "Etc/GMT+3", Nautical: true}, nil},
+		{"oceans variant", VariantWithOceans, false, db.Result{}, db.ErrNotFound},
+		{"oceans variant with nautical fallback", VariantWithOceans, true, db.Result{TzID: "Etc/GMT+3", Nautical: true}, nil},
+		{"no variant", "", false, db.Result{}, db.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tzDB, err := LoadDatabase(TzSchema{
+				DatabaseName:     "../db/testdata/timezones.zip",
+				Variant:          tt.variant,
+				NauticalFallback: tt.nautical,
+			})
+			assert.NoError(t, err)
+			got, err := tzDB.Resolve(30, -40)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -3,27 +3,43 @@ package web
 import (
 	"context"
 	"crypto/subtle"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/labstack/echo/v5"
 	"github.com/labstack/echo/v5/middleware"
 	"github.com/noandrea/geo2tz/v2/db"
-	"github.com/noandrea/geo2tz/v2/helpers"
 
 	"golang.org/x/crypto/blake2b"
+	"google.golang.org/grpc"
 )
 
 // constant valuses for lat / lon
 const (
 	Latitude        = "lat"
 	Longitude       = "lon"
+	All             = "all"
 	compareEquals   = 1
-	teardownTimeout = 10 * time.Second
+	teardownTimeout = 10 * time.Second // default time to drain the in-flight requests on shutdown
+	// batchItemMaxBytes is the average size allowed for the items of a batch request,
+	// that limits the size of the request body together with the maximum batch size
+	batchItemMaxBytes = 4 * 1024
+)
+
+var (
+	errInvalidBatch     = errors.New("a json array is required")
+	errInvalidBatchItem = errors.New("a json object is required")
+	errBatchTooLarge    = errors.New("batch too large")
 )
 
 // hash calculate the hash of a string
@@ -38,35 +54,77 @@ func isEq(expectedTokenHash []byte, actualToken string) bool {
 }
 
 type Server struct {
-	config          ConfigSchema
-	tzDB            db.TzDBIndex
-	tzRelease       TzRelease
-	echo            *echo.Echo
-	authEnabled     bool
-	authHashedToken []byte
-	shutdownCtx     context.Context
-	cancel          context.CancelFunc
-	done            chan struct{}
+	config       ConfigSchema
+	dataset      atomic.Pointer[dataset]
+	reloadMu     sync.Mutex
+	updateStatus atomic.Pointer[UpdateStatus]
+	metrics      *metrics
+	ready        atomic.Bool
+	draining     atomic.Bool
+	shutdownErr  error
+	echo         *echo.Echo
+	authEnabled  bool
+	authTokens   []authToken
+	rateLimiter  *rateLimiter
+	shutdownCtx  context.Context
+	cancel       context.CancelFunc
+	done         chan struct{}
+
+	// grpcServer serves the gRPC API, when it has a listen address
+	grpcServer      *grpc.Server
+	grpcShutdownErr error
 }
 
 func (server *Server) Start() error {
 	defer close(server.done)
+	timeout := server.config.Web.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = teardownTimeout
+	}
 	sc := echo.StartConfig{
 		Address:         server.config.Web.ListenAddress,
 		HideBanner:      true,
-		GracefulTimeout: teardownTimeout,
+		GracefulTimeout: timeout,
+		// called before Start returns, when the in-flight requests are not completed in time
+		OnShutdownError: func(err error) {
+			server.shutdownErr = err
+		},
+	}
+	if server.grpcServer == nil {
+		return sc.Start(server.shutdownCtx, server.echo)
+	}
+
+	// the gRPC API is served alongside the REST API and stopped with it
+	lis, err := net.Listen("tcp", server.config.Web.GRPCListenAddress)
+	if err != nil {
+		return err
 	}
-	return sc.Start(server.shutdownCtx, server.echo)
+	var grpcErr error
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		grpcErr = server.serveGRPC(lis, timeout)
+	})
+	err = sc.Start(server.shutdownCtx, server.echo)
+	server.cancel()
+	wg.Wait()
+	return errors.Join(err, grpcErr)
 }
 
+// Teardown stops the server, waiting for the in-flight requests to complete
+// within the shutdown timeout, it returns ErrorShutdownTimeout if they do not
 func (server *Server) Teardown() error {
 	if server.cancel == nil {
 		return nil
 	}
+	// the server is not ready anymore as soon as the drain starts
+	server.draining.Store(true)
 	// cancelling the context triggers the graceful shutdown handled inside
 	// StartConfig.Start; wait for it to return before reporting completion.
 	server.cancel()
 	<-server.done
+	if server.shutdownErr != nil || server.grpcShutdownErr != nil {
+		return errors.Join(ErrorShutdownTimeout, server.shutdownErr, server.grpcShutdownErr)
+	}
 	return nil
 }
 
@@ -77,79 +135,309 @@ func NewServer(config ConfigSchema) (*Server, error) {
 	server.shutdownCtx, server.cancel = context.WithCancel(context.Background())
 	server.done = make(chan struct{})
 
-	// load the database
-	tzDB, err := db.NewGeo2TzRTreeIndexFromGeoJSON(config.Tz.DatabaseName)
+	if config.Web.MetricsEnabled {
+		server.metrics = newMetrics()
+	}
+
+	// load the database and the release info
+	ds, err := loadDataset(config.Tz, server.metrics)
 	if err != nil {
-		return nil, errors.Join(ErrorDatabaseFileNotFound, err)
+		return nil, err
 	}
-	server.tzDB = tzDB
+	server.dataset.Store(ds)
+	server.metrics.observeDataset(ds)
+	server.ready.Store(true)
 
 	// check token authorization
-	server.authHashedToken = hash(config.Web.AuthTokenValue)
-	if len(config.Web.AuthTokenValue) > 0 {
-		server.echo.Logger.Info("Authorization enabled")
+	if server.authTokens, err = loadTokens(config.Web); err != nil {
+		return nil, err
+	}
+	if len(server.authTokens) > 0 {
+		server.echo.Logger.Info("Authorization enabled", "clients", len(server.authTokens))
 		server.authEnabled = true
 	} else {
 		server.echo.Logger.Info("Authorization disabled")
 	}
+	server.rateLimiter = newRateLimiter(config.Web)
 
 	// v5's CORS() no longer defaults to allowing all origins, so pass "*"
 	// explicitly to keep the previous allow-all behavior.
 	server.echo.Use(middleware.CORS("*"))
-	server.echo.Use(middleware.RequestLogger())
-	server.echo.Use(middleware.Recover())
-
-	// load the release info
-	if err = helpers.LoadJSON(config.Tz.VersionFile, &server.tzRelease); err != nil {
-		err = errors.Join(ErrorVersionFileNotFound, err, fmt.Errorf("error loading the timezone release info: %w", err))
-		return nil, err
+	if server.metrics != nil {
+		server.echo.Use(server.metrics.middleware)
 	}
+	server.echo.Use(server.requestLogger())
+	server.echo.Use(middleware.Recover())
 
 	// register routes
+	server.echo.GET("/healthz", server.handleHealth)
+	server.echo.GET("/readyz", server.handleReady)
 	server.echo.GET("/tz/:lat/:lon", server.handleTzRequest)
 	server.echo.GET("/tz/version", server.handleTzVersion)
+	server.echo.POST("/tz/batch", server.handleTzBatchRequest)
+	server.echo.POST("/tz/stream", server.handleTzStream)
+	if server.metrics != nil {
+		server.echo.GET("/metrics", server.metrics.handler())
+	}
+	// the admin routes are available only when the authorization is enabled
+	// and restricted to the admin clients
+	if server.authEnabled && len(config.Web.AdminClients) > 0 {
+		server.echo.POST("/admin/reload", server.handleReload)
+	}
+	if config.Web.GRPCListenAddress != "" {
+		server.grpcServer = server.newGRPCServer()
+	}
 
 	return &server, nil
 }
 
+// isAuthorized verifies the request token, if the authorization is enabled,
+// the name of the authenticated client is attached to the request logs and metrics
+func (server *Server) isAuthorized(c *echo.Context) bool {
+	if !server.authEnabled {
+		return true
+	}
+	client, ok := server.authenticate(c)
+	if !ok {
+		// the token is never logged
+		server.echo.Logger.Error("request unauthorized, invalid token")
+		return false
+	}
+	c.Set(clientKey, client)
+	c.SetLogger(c.Logger().With(clientKey, client))
+	return true
+}
+
 func (server *Server) handleTzRequest(c *echo.Context) error {
 	// token verification
-	if server.authEnabled {
-		requestToken := c.QueryParam(server.config.Web.AuthTokenParamName)
-		if !isEq(server.authHashedToken, requestToken) {
-			server.echo.Logger.Error("request unauthorized, invalid token", "token", requestToken)
-			return c.JSON(http.StatusUnauthorized, map[string]interface{}{"message": "unauthorized"})
-		}
+	if !server.isAuthorized(c) {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"message": "unauthorized"})
+	}
+	if code, reply := server.rateLimit(c, 1); code != http.StatusOK {
+		return c.JSON(code, reply)
 	}
 	// parse latitude
 	lat, err := parseCoordinate(c.Param(Latitude), Latitude)
 	if err != nil {
-		server.echo.Logger.Error("error parsing latitude", "error", err)
+		server.logError("error parsing latitude", err)
 		return c.JSON(http.StatusBadRequest, newErrResponse(err))
 	}
 	// parse longitude
 	lon, err := parseCoordinate(c.Param(Longitude), Longitude)
 	if err != nil {
-		server.echo.Logger.Error("error parsing longitude", "error", err)
+		server.logError("error parsing longitude", err)
 		return c.JSON(http.StatusBadRequest, newErrResponse(err))
 	}
 
-	// query the coordinates
-	res, err := server.tzDB.Lookup(lat, lon)
+	// parse the optional instant for the time details
+	withTime := c.QueryParams().Has(At)
+	at, err := parseInstant(c.QueryParam(At))
+	if withTime && err != nil {
+		server.logError("error parsing instant", err)
+		return c.JSON(http.StatusBadRequest, newErrResponse(err))
+	}
+	// parse the optional number of transitions
+	transitions := 0
+	if c.QueryParams().Has(Transitions) {
+		if transitions, err = parseTransitions(c.QueryParam(Transitions)); err != nil {
+			server.logError("error parsing transitions", err)
+			return c.JSON(http.StatusBadRequest, newErrResponse(err))
+		}
+	}
+
+	// parse the optional flag to return all the matching zones
+	all := false
+	if c.QueryParams().Has(All) {
+		if all, err = strconv.ParseBool(c.QueryParam(All)); err != nil {
+			err = fmt.Errorf("invalid value for %s, a boolean is required (eg. true)", All)
+			server.logError("error parsing all", err)
+			return c.JSON(http.StatusBadRequest, newErrResponse(err))
+		}
+	}
+
+	// query the coordinates, using the same database for all the queries of the request
+	tzDB := server.dataset.Load().tzDB
+	code, reply := server.lookup(tzDB, lat, lon)
+	if code != http.StatusOK {
+		return c.JSON(code, reply)
+	}
+	if all {
+		zones, err := tzDB.LookupAll(lat, lon)
+		if err != nil && !errors.Is(err, db.ErrNotFound) {
+			server.logError("error querying the timezone db", err)
+			return c.JSON(http.StatusInternalServerError, newErrResponse(err))
+		}
+		// fallback results are not contained by any zone
+		if zones == nil {
+			zones = []string{}
+		}
+		reply["zones"] = zones
+		reply["ambiguous"] = len(zones) > 1
+	}
+	tzName := reply["tz"].(string)
+	if withTime {
+		details, err := zoneInfo(tzName, at)
+		if err != nil {
+			server.logError("error computing the time details", err)
+			return c.JSON(http.StatusInternalServerError, newErrResponse(err))
+		}
+		maps.Copy(reply, details)
+	}
+	if transitions > 0 {
+		if reply[Transitions], err = zoneTransitions(tzName, at, transitions); err != nil {
+			server.logError("error computing the transitions", err)
+			return c.JSON(http.StatusInternalServerError, newErrResponse(err))
+		}
+	}
+	return c.JSON(code, reply)
+}
+
+// lookup queries the timezone database and returns the reply with the matching http status
+func (server *Server) lookup(tzDB db.TzDBIndex, lat, lon float64) (int, map[string]any) {
+	res, err := server.resolve(tzDB, lat, lon)
 	switch err {
 	case nil:
-		tzr := newTzResponse(res, lat, lon)
-		return c.JSON(http.StatusOK, tzr)
+		reply := newTzResponse(res.TzID, lat, lon)
+		if res.Override {
+			reply["override"] = true
+		}
+		if res.Fallback {
+			reply["fallback"] = true
+			reply["distance_meters"] = math.Round(res.Distance*10) / 10
+		}
+		if res.Nautical {
+			reply["nautical"] = true
+		}
+		return http.StatusOK, reply
 	case db.ErrNotFound:
 		notFoundErr := fmt.Errorf("timezone not found for coordinates %f,%f", lat, lon)
-		server.echo.Logger.Error("error querying the timezone db", "error", notFoundErr)
-		return c.JSON(http.StatusNotFound, newErrResponse(notFoundErr))
+		server.logError("error querying the timezone db", notFoundErr)
+		return http.StatusNotFound, newErrResponse(notFoundErr)
 	default:
-		server.echo.Logger.Error("error querying the timezone db", "error", err)
-		return c.JSON(http.StatusInternalServerError, newErrResponse(err))
+		server.logError("error querying the timezone db", err)
+		return http.StatusInternalServerError, newErrResponse(err)
 	}
 }
 
+// resolve queries the timezone database, recording the lookup duration
+func (server *Server) resolve(tzDB db.TzDBIndex, lat, lon float64) (db.Result, error) {
+	start := time.Now()
+	res, err := tzDB.Resolve(lat, lon)
+	server.metrics.observeLookupDuration(start)
+	return res, err
+}
+
+// BatchItem is a single coordinate of a batch request
+type BatchItem struct {
+	ID  any         `json:"id,omitempty"`
+	Lat json.Number `json:"lat"`
+	Lon json.Number `json:"lon"`
+}
+
+func (server *Server) handleTzBatchRequest(c *echo.Context) error {
+	// token verification
+	if !server.isAuthorized(c) {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"message": "unauthorized"})
+	}
+	items, err := server.decodeBatch(c)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, errBatchTooLarge):
+		err = fmt.Errorf("batch size exceeds the maximum of %d", server.config.Web.BatchMaxSize)
+		return c.JSON(http.StatusRequestEntityTooLarge, newErrResponse(err))
+	case errors.As(err, &tooLarge):
+		err = fmt.Errorf("batch request exceeds the maximum size of %d bytes", tooLarge.Limit)
+		return c.JSON(http.StatusRequestEntityTooLarge, newErrResponse(err))
+	case err != nil:
+		server.logError("error parsing batch request", err)
+		return c.JSON(http.StatusBadRequest, newErrResponse(fmt.Errorf("invalid batch request, a json array of coordinates is required")))
+	case len(items) == 0:
+		return c.JSON(http.StatusBadRequest, newErrResponse(fmt.Errorf("empty batch request")))
+	}
+	// batches are limited by the number of coordinates
+	if code, reply := server.rateLimit(c, len(items)); code != http.StatusOK {
+		return c.JSON(code, reply)
+	}
+
+	tzDB := server.dataset.Load().tzDB
+	replies := make([]map[string]any, len(items))
+	for i, data := range items {
+		item, err := parseBatchItem(data)
+		if err != nil {
+			replies[i] = newErrResponse(fmt.Errorf("invalid item %d, a json object with the coordinates is required", i))
+			continue
+		}
+		replies[i] = server.lookupItem(tzDB, item)
+		if item.ID != nil {
+			replies[i]["id"] = item.ID
+		}
+	}
+	return c.JSON(http.StatusOK, replies)
+}
+
+// parseBatchItem decodes an item of a batch request, the coordinates that are not
+// numbers are kept as they are to be reported as invalid by the lookup
+func parseBatchItem(data json.RawMessage) (BatchItem, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return BatchItem{}, err
+	}
+	if fields == nil {
+		return BatchItem{}, errInvalidBatchItem
+	}
+	item := BatchItem{Lat: streamCoordinate(fields[Latitude]), Lon: streamCoordinate(fields[Longitude])}
+	if id, ok := fields["id"]; ok {
+		if err := json.Unmarshal(id, &item.ID); err != nil {
+			return BatchItem{}, err
+		}
+	}
+	return item, nil
+}
+
+// decodeBatch decodes the items of a batch request one at a time, so that the
+// requests larger than the maximum batch size are rejected without reading them,
+// the items are decoded on their own so that each of them fails separately
+func (server *Server) decodeBatch(c *echo.Context) ([]json.RawMessage, error) {
+	body := c.Request().Body
+	maxSize := server.config.Web.BatchMaxSize
+	if maxSize > 0 {
+		body = http.MaxBytesReader(c.Response(), body, int64(maxSize)*batchItemMaxBytes)
+	}
+	dec := json.NewDecoder(body)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, errors.Join(errInvalidBatch, err)
+	}
+	var items []json.RawMessage
+	for dec.More() {
+		if maxSize > 0 && len(items) == maxSize {
+			return nil, errBatchTooLarge
+		}
+		var item json.RawMessage
+		if err := dec.Decode(&item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// lookupItem validates and queries the coordinates of a single batch item
+func (server *Server) lookupItem(tzDB db.TzDBIndex, item BatchItem) map[string]any {
+	lat, err := parseCoordinate(item.Lat.String(), Latitude)
+	if err != nil {
+		return newErrResponse(err)
+	}
+	lon, err := parseCoordinate(item.Lon.String(), Longitude)
+	if err != nil {
+		return newErrResponse(err)
+	}
+	_, reply := server.lookup(tzDB, lat, lon)
+	return reply
+}
+
 func newTzResponse(tzName string, lat, lon float64) map[string]any {
 	return map[string]any{"tz": tzName, "coords": map[string]float64{Latitude: lat, Longitude: lon}}
 }
@@ -159,7 +447,24 @@ func newErrResponse(err error) map[string]any {
 }
 
 func (server *Server) handleTzVersion(c *echo.Context) error {
-	return c.JSON(http.StatusOK, server.tzRelease)
+	// the update status is reported only when the background updates are enabled
+	return c.JSON(http.StatusOK, struct {
+		TzRelease
+		AutoUpdate *UpdateStatus `json:"auto_update,omitempty"`
+	}{server.Release(), server.updateStatus.Load()})
+}
+
+// ParseLatLon parses and validates a pair of coordinates as the API does
+func ParseLatLon(lat, lon string) (float64, float64, error) {
+	latitude, err := parseCoordinate(lat, Latitude)
+	if err != nil {
+		return 0, 0, err
+	}
+	longitude, err := parseCoordinate(lon, Longitude)
+	if err != nil {
+		return 0, 0, err
+	}
+	return latitude, longitude, nil
 }
 
 // parseCoordinate parse a string into a coordinate
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -52,6 +54,18 @@ func Test_parseCoordinate(t *testing.T) {
 	}
 }
 
+func TestParseLatLon(t *testing.T) {
+	lat, lon, err := ParseLatLon("41.9028", "12.4964")
+	assert.NoError(t, err)
+	assert.Equal(t, 41.9028, lat)
+	assert.Equal(t, 12.4964, lon)
+
+	_, _, err = ParseLatLon("91", "12.4964")
+	assert.EqualError(t, err, "lat value 91 out of range (-90/+90)")
+	_, _, err = ParseLatLon("41.9028", "east")
+	assert.EqualError(t, err, "invalid type for lon, a number is required (eg. 45.3123)")
+}
+
 func Test_hash(t *testing.T) {
 	tests := []struct {
 		name string
@@ -181,6 +195,7 @@ func Test_TzRequest(t *testing.T) {
 		name      string
 		lat       string
 		lon       string
+		query     string
 		wantCode  int
 		wantReply string
 	}{
@@ -188,6 +203,7 @@ func Test_TzRequest(t *testing.T) {
 			"PASS: valid coordinates",
 			"51.477811",
 			"0",
+			"",
 			http.StatusOK,
 			`{"coords":{"lat":51.477811,"lon":0},"tz":"Europe/London"}`,
 		},
@@ -195,13 +211,71 @@ func Test_TzRequest(t *testing.T) {
 			"PASS: valid coordinates",
 			"41.9028",
 			"12.4964",
+			"",
 			http.StatusOK,
 			`{"coords":{"lat":41.9028,"lon":12.4964},"tz":"Europe/Rome"}`,
 		},
+		{
+			"PASS: valid coordinates with time details",
+			"41.9028",
+			"12.4964",
+			"?at=2024-07-15T12:00:00Z",
+			http.StatusOK,
+			`{"abbreviation":"CEST","coords":{"lat":41.9028,"lon":12.4964},"is_dst":true,"local_time":"2024-07-15T14:00:00+02:00","tz":"Europe/Rome","utc_offset_seconds":7200}`,
+		},
+		{
+			"PASS: valid coordinates with time details as unix seconds",
+			"41.9028",
+			"12.4964",
+			"?at=1705320000",
+			http.StatusOK,
+			`{"abbreviation":"CET","coords":{"lat":41.9028,"lon":12.4964},"is_dst":false,"local_time":"2024-01-15T13:00:00+01:00","tz":"Europe/Rome","utc_offset_seconds":3600}`,
+		},
+		{
+			"PASS: valid coordinates with transitions",
+			"41.9028",
+			"12.4964",
+			"?at=1705320000&transitions=1",
+			http.StatusOK,
+			`{"abbreviation":"CET","coords":{"lat":41.9028,"lon":12.4964},"is_dst":false,"local_time":"2024-01-15T13:00:00+01:00","transitions":[{"at":"2024-03-31T01:00:00Z","is_dst":true,"new_abbreviation":"CEST","new_utc_offset_seconds":7200,"old_abbreviation":"CET","old_utc_offset_seconds":3600}],"tz":"Europe/Rome","utc_offset_seconds":3600}`,
+		},
+		{
+			"FAIL: invalid transitions",
+			"41.9028",
+			"12.4964",
+			"?transitions=100",
+			http.StatusBadRequest,
+			`{"message":"invalid value for transitions, a number between 1 and 20 is required"}`,
+		},
+		{
+			"PASS: valid coordinates with all the zones",
+			"41.9028",
+			"12.4964",
+			"?all=true",
+			http.StatusOK,
+			`{"ambiguous":false,"coords":{"lat":41.9028,"lon":12.4964},"tz":"Europe/Rome","zones":["Europe/Rome"]}`,
+		},
+		{
+			"FAIL: invalid all",
+			"41.9028",
+			"12.4964",
+			"?all=maybe",
+			http.StatusBadRequest,
+			`{"message":"invalid value for all, a boolean is required (eg. true)"}`,
+		},
+		{
+			"FAIL: invalid instant",
+			"41.9028",
+			"12.4964",
+			"?at=yesterday",
+			http.StatusBadRequest,
+			`{"message":"invalid value for at, a RFC 3339 timestamp or unix seconds are required (eg. 2024-03-31T01:30:00Z)"}`,
+		},
 		{
 			"FAIL: invalid latitude",
 			"100",
 			"11.831443",
+			"",
 			http.StatusBadRequest,
 			`{"message":"lat value 100 out of range (-90/+90)"}`,
 		},
@@ -209,6 +283,7 @@ func Test_TzRequest(t *testing.T) {
 			"FAIL: invalid longitude",
 			"43.42582",
 			"200",
+			"",
 			http.StatusBadRequest,
 			`{"message":"lon value 200 out of range (-180/+180)"}`,
 		},
@@ -216,6 +291,7 @@ func Test_TzRequest(t *testing.T) {
 			"FAIL: invalid latitude and longitude",
 			"100",
 			"200",
+			"",
 			http.StatusBadRequest,
 			`{"message":"lat value 100 out of range (-90/+90)"}`,
 		},
@@ -223,7 +299,7 @@ func Test_TzRequest(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
 			rec := httptest.NewRecorder()
 			c := server.echo.NewContext(req, rec)
 			c.SetPath("/tz/:lat/:lon")
@@ -240,3 +316,207 @@ func Test_TzRequest(t *testing.T) {
 		})
 	}
 }
+
+func Test_TzBatchRequest(t *testing.T) {
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  "../tzdata/version.json",
+			DatabaseName: "../tzdata/timezones.zip",
+		},
+		Web: WebSchema{
+			BatchMaxSize: 3,
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		body      string
+		wantCode  int
+		wantReply string
+	}{
+		{
+			"PASS: valid batch",
+			`[{"id":"rome","lat":41.9028,"lon":12.4964},{"id":2,"lat":"52.52","lon":"13.405"},{"lat":41.9028,"lon":12.4964}]`,
+			http.StatusOK,
+			`[{"coords":{"lat":41.9028,"lon":12.4964},"id":"rome","tz":"Europe/Rome"},{"coords":{"lat":52.52,"lon":13.405},"id":2,"tz":"Europe/Berlin"},{"coords":{"lat":41.9028,"lon":12.4964},"tz":"Europe/Rome"}]`,
+		},
+		{
+			"PASS: per item errors",
+			`[{"id":1,"lat":100,"lon":12.4964},{"id":2,"lat":41.9028},{"id":3,"lat":41.9028,"lon":12.4964}]`,
+			http.StatusOK,
+			`[{"id":1,"message":"lat value 100 out of range (-90/+90)"},{"id":2,"message":"empty coordinates value"},{"coords":{"lat":41.9028,"lon":12.4964},"id":3,"tz":"Europe/Rome"}]`,
+		},
+		{
+			"PASS: per item decoding errors",
+			`[{"id":1,"lat":41.9028,"lon":12.4964},{"id":2,"lat":null,"lon":true},5]`,
+			http.StatusOK,
+			`[{"coords":{"lat":41.9028,"lon":12.4964},"id":1,"tz":"Europe/Rome"},{"id":2,"message":"empty coordinates value"},` +
+				`{"message":"invalid item 2, a json object with the coordinates is required"}]`,
+		},
+		{
+			"PASS: per item decoding errors of null and nested values",
+			`[null,{"id":"x","lat":[1],"lon":{}}]`,
+			http.StatusOK,
+			`[{"message":"invalid item 0, a json object with the coordinates is required"},{"id":"x","message":"invalid type for lat, a number is required (eg. 45.3123)"}]`,
+		},
+		{
+			"FAIL: batch too large",
+			`[{"lat":1,"lon":1},{"lat":1,"lon":1},{"lat":1,"lon":1},{"lat":1,"lon":1}]`,
+			http.StatusRequestEntityTooLarge,
+			`{"message":"batch size exceeds the maximum of 3"}`,
+		},
+		{
+			"FAIL: body too large",
+			`[{"id":"` + strings.Repeat("x", 3*batchItemMaxBytes) + `","lat":1,"lon":1}]`,
+			http.StatusRequestEntityTooLarge,
+			`{"message":"batch request exceeds the maximum size of 12288 bytes"}`,
+		},
+		{
+			"FAIL: truncated array",
+			`[{"lat":1,"lon":1}`,
+			http.StatusBadRequest,
+			`{"message":"invalid batch request, a json array of coordinates is required"}`,
+		},
+		{
+			"FAIL: empty batch",
+			`[]`,
+			http.StatusBadRequest,
+			`{"message":"empty batch request"}`,
+		},
+		{
+			"FAIL: invalid body",
+			`{"lat":1,"lon":1}`,
+			http.StatusBadRequest,
+			`{"message":"invalid batch request, a json array of coordinates is required"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tz/batch", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := server.echo.NewContext(req, rec)
+			c.SetPath("/tz/batch")
+
+			// Assertions
+			if assert.NoError(t, server.handleTzBatchRequest(c)) {
+				assert.Equal(t, tt.wantCode, rec.Code)
+				assert.Equal(t, tt.wantReply, strings.TrimSpace(rec.Body.String()))
+			}
+		})
+	}
+}
+
+func Test_TzRequestFallback(t *testing.T) {
+	// the test dataset has a hole in Europe/Rome for Vatican City, but not the Europe/Vatican zone
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:         "../tzdata/version.json",
+			DatabaseName:        "../db/testdata/timezones.zip",
+			FallbackMaxDistance: 1000,
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := server.echo.NewContext(req, rec)
+	c.SetPath("/tz/:lat/:lon")
+	c.SetPathValues(echo.PathValues{
+		{Name: Latitude, Value: "41.9029"},
+		{Name: Longitude, Value: "12.4534"},
+	})
+
+	if assert.NoError(t, server.handleTzRequest(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var reply struct {
+			Tz       string  `json:"tz"`
+			Fallback bool    `json:"fallback"`
+			Distance float64 `json:"distance_meters"`
+		}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+		assert.Equal(t, "Europe/Rome", reply.Tz)
+		assert.True(t, reply.Fallback)
+		assert.Greater(t, reply.Distance, 0.0)
+		assert.LessOrEqual(t, reply.Distance, 1000.0)
+	}
+}
+
+func Test_TzRequestNautical(t *testing.T) {
+	// the test dataset has no ocean zones
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:      "../tzdata/version.json",
+			DatabaseName:     "../db/testdata/timezones.zip",
+			NauticalFallback: true,
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := server.echo.NewContext(req, rec)
+	c.SetPath("/tz/:lat/:lon")
+	c.SetPathValues(echo.PathValues{
+		{Name: Latitude, Value: "30"},
+		{Name: Longitude, Value: "-40"},
+	})
+
+	if assert.NoError(t, server.handleTzRequest(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `{"coords":{"lat":30,"lon":-40},"nautical":true,"tz":"Etc/GMT+3"}`, strings.TrimSpace(rec.Body.String()))
+	}
+}
+
+func Test_TzRequestOverride(t *testing.T) {
+	// a site in Berlin that follows the time of Tokyo
+	overrides := filepath.Join(t.TempDir(), "overrides.geojson")
+	err := os.WriteFile(overrides, []byte(`{"type":"FeatureCollection","features":[{"type":"Feature","properties":{"tzid":"Asia/Tokyo"},`+
+		`"geometry":{"type":"Polygon","coordinates":[[[13.3,52.4],[13.5,52.4],[13.5,52.6],[13.3,52.6],[13.3,52.4]]]}}]}`), 0o600)
+	assert.NoError(t, err)
+
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:   "../tzdata/version.json",
+			DatabaseName:  "../db/testdata/timezones.zip",
+			OverridesFile: overrides,
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		lat, lon string
+		want     string
+	}{
+		{"override", "52.52", "13.405", `{"coords":{"lat":52.52,"lon":13.405},"override":true,"tz":"Asia/Tokyo"}`},
+		{"dataset", "52.3", "13.405", `{"coords":{"lat":52.3,"lon":13.405},"tz":"Europe/Berlin"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			c := server.echo.NewContext(req, rec)
+			c.SetPath("/tz/:lat/:lon")
+			c.SetPathValues(echo.PathValues{
+				{Name: Latitude, Value: tt.lat},
+				{Name: Longitude, Value: tt.lon},
+			})
+			if assert.NoError(t, server.handleTzRequest(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, tt.want, strings.TrimSpace(rec.Body.String()))
+			}
+		})
+	}
+
+	// the server does not start with an invalid overrides file
+	settings.Tz.OverridesFile = filepath.Join(t.TempDir(), "not_found.geojson")
+	_, err = NewServer(settings)
+	assert.ErrorIs(t, err, ErrorOverridesFile)
+}
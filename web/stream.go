@@ -0,0 +1,136 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+)
+
+const (
+	// MIMEApplicationNDJSON is the content type of the newline delimited json streams
+	MIMEApplicationNDJSON = "application/x-ndjson"
+	// streamMaxLineSize is the maximum size of a line of a stream request
+	streamMaxLineSize = 64 * 1024
+)
+
+// errLineTooLong is returned for the lines of a stream request longer than streamMaxLineSize
+var errLineTooLong = errors.New("line too long")
+
+// handleTzStream resolves the newline delimited coordinates of the request body, replying
+// with a line for each of them as soon as it is resolved; the other fields of the lines
+// are returned as they are and the errors are reported per line, without stopping the stream
+func (server *Server) handleTzStream(c *echo.Context) error {
+	// token verification
+	if !server.isAuthorized(c) {
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{"message": "unauthorized"})
+	}
+	// the replies are written while the request is still being read, the writes block
+	// when the client does not keep up and so does the reading of the next lines
+	rc := http.NewResponseController(c.Response())
+	_ = rc.EnableFullDuplex()
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationNDJSON)
+	c.Response().WriteHeader(http.StatusOK)
+	// the headers are sent at once, the clients may wait for them before sending the lines
+	if err := rc.Flush(); err != nil {
+		return err
+	}
+
+	r := bufio.NewReaderSize(c.Request().Body, streamMaxLineSize)
+	enc := json.NewEncoder(c.Response())
+	write := func(reply map[string]any) error {
+		if err := enc.Encode(reply); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	for line := 1; ; line++ {
+		data, err := readLine(r)
+		switch {
+		case errors.Is(err, errLineTooLong):
+			// the line is skipped and the stream goes on from the next one
+			reply := newErrResponse(fmt.Errorf("line %d exceeds the maximum size of %d bytes", line, streamMaxLineSize))
+			reply["line"] = line
+			if err := write(reply); err != nil {
+				return err
+			}
+			continue
+		case err != nil && !errors.Is(err, io.EOF):
+			// the stream cannot be resumed after a read error, it is reported as the last line
+			server.logError("error reading the stream request", err)
+			return enc.Encode(newErrResponse(err))
+		}
+		if len(bytes.TrimSpace(data)) > 0 {
+			reply, lookupErr := server.lookupLine(c, line, data)
+			if lookupErr != nil {
+				return lookupErr
+			}
+			if err := write(reply); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+}
+
+// readLine reads a line of up to streamMaxLineSize bytes, the longer lines are
+// skipped up to the next newline and errLineTooLong is returned
+func readLine(r *bufio.Reader) ([]byte, error) {
+	data, err := r.ReadSlice('\n')
+	if !errors.Is(err, bufio.ErrBufferFull) {
+		return data, err
+	}
+	for errors.Is(err, bufio.ErrBufferFull) {
+		_, err = r.ReadSlice('\n')
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return nil, errLineTooLong
+}
+
+// lookupLine validates and queries the coordinates of a line of a stream request,
+// returning the fields of the line together with the reply; the lines wait for the
+// rate limits, it fails only if the request is canceled while waiting
+func (server *Server) lookupLine(c *echo.Context, line int, data []byte) (map[string]any, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		reply := newErrResponse(fmt.Errorf("invalid line %d, a json object with the coordinates is required", line))
+		reply["line"] = line
+		return reply, nil
+	}
+	reply := make(map[string]any, len(fields)+2)
+	for k, v := range fields {
+		reply[k] = v
+	}
+	// streams are limited by each of the coordinates, and slowed down to the limits
+	ctx := c.Request().Context()
+	if err := server.waitRateLimit(ctx, rateLimitKey(requestClient(c), c.RealIP()), 1); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		reply["message"] = err.Error()
+		return reply, nil
+	}
+	item := BatchItem{Lat: streamCoordinate(fields[Latitude]), Lon: streamCoordinate(fields[Longitude])}
+	maps.Copy(reply, server.lookupItem(server.dataset.Load().tzDB, item))
+	return reply, nil
+}
+
+// streamCoordinate returns the coordinate of a line field, the values that are not
+// numbers are returned as they are to be reported as invalid
+func streamCoordinate(v json.RawMessage) json.Number {
+	var n json.Number
+	if err := json.Unmarshal(v, &n); err != nil {
+		return json.Number(v)
+	}
+	return n
+}
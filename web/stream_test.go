@@ -0,0 +1,136 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleTzStream(t *testing.T) {
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  "../tzdata/version.json",
+			DatabaseName: "../db/testdata/timezones.zip",
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			"PASS: extra fields are returned as they are",
+			`{"lat":41.9028,"lon":12.4964,"id":"a1","meta":{"speed":12.5,"tags":["x"]}}` + "\n" +
+				`{"lat":"52.52","lon":13.405,"id":2}`,
+			[]string{
+				`{"coords":{"lat":41.9028,"lon":12.4964},"id":"a1","lat":41.9028,"lon":12.4964,"meta":{"speed":12.5,"tags":["x"]},"tz":"Europe/Rome"}`,
+				`{"coords":{"lat":52.52,"lon":13.405},"id":2,"lat":"52.52","lon":13.405,"tz":"Europe/Berlin"}`,
+			},
+		},
+		{
+			"PASS: errors are reported per line",
+			"{\"lat\":100,\"lon\":0,\"id\":1}\n\n{\"lon\":0,\"id\":2}\nnot json\n{\"lat\":true,\"lon\":0}\r\n{\"lat\":41.9028,\"lon\":12.4964,\"id\":5}\n",
+			[]string{
+				`{"id":1,"lat":100,"lon":0,"message":"lat value 100 out of range (-90/+90)"}`,
+				`{"id":2,"lon":0,"message":"empty coordinates value"}`,
+				`{"line":4,"message":"invalid line 4, a json object with the coordinates is required"}`,
+				`{"lat":true,"lon":0,"message":"invalid type for lat, a number is required (eg. 45.3123)"}`,
+				`{"coords":{"lat":41.9028,"lon":12.4964},"id":5,"lat":41.9028,"lon":12.4964,"tz":"Europe/Rome"}`,
+			},
+		},
+		{
+			"PASS: empty stream",
+			"",
+			nil,
+		},
+		{
+			"PASS: lines too long are skipped",
+			`{"lat":41.9028,"lon":12.4964}` + "\n" + `{"id":"` + strings.Repeat("x", 3*streamMaxLineSize) + `"}` + "\n" +
+				`{"lat":52.52,"lon":13.405}` + "\n" + `{"id":"` + strings.Repeat("x", streamMaxLineSize) + `"}`,
+			[]string{
+				`{"coords":{"lat":41.9028,"lon":12.4964},"lat":41.9028,"lon":12.4964,"tz":"Europe/Rome"}`,
+				`{"line":2,"message":"line 2 exceeds the maximum size of 65536 bytes"}`,
+				`{"coords":{"lat":52.52,"lon":13.405},"lat":52.52,"lon":13.405,"tz":"Europe/Berlin"}`,
+				`{"line":4,"message":"line 4 exceeds the maximum size of 65536 bytes"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tz/stream", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			server.echo.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, MIMEApplicationNDJSON, rec.Header().Get("Content-Type"))
+			var got []string
+			for line := range strings.Lines(rec.Body.String()) {
+				got = append(got, strings.TrimSuffix(line, "\n"))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestServer_handleTzStreamFullDuplex(t *testing.T) {
+	settings := ConfigSchema{
+		Tz: TzSchema{
+			VersionFile:  "../tzdata/version.json",
+			DatabaseName: "../db/testdata/timezones.zip",
+		},
+		Web: WebSchema{
+			AuthTokenValue: "secret",
+			RateLimit:      10,
+			RateLimitBurst: 2,
+			DailyQuota:     3,
+		},
+	}
+	server, err := NewServer(settings)
+	assert.NoError(t, err)
+	ts := httptest.NewServer(server.echo)
+	defer ts.Close()
+
+	// unauthorized streams are rejected
+	resp, err := http.Post(ts.URL+"/tz/stream", MIMEApplicationNDJSON, bytes.NewBufferString(`{"lat":1,"lon":1}`))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// each reply is received before the next line is sent
+	pr, pw := io.Pipe()
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/tz/stream", pr)
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	replies := bufio.NewScanner(resp.Body)
+	start := time.Now()
+	for i := range 4 {
+		_, err = io.WriteString(pw, `{"lat":41.9028,"lon":12.4964}`+"\n")
+		assert.NoError(t, err)
+		assert.True(t, replies.Scan())
+		switch {
+		case i < 2:
+			assert.Contains(t, replies.Text(), `"tz":"Europe/Rome"`)
+		case i == 2:
+			// the line over the burst waits for the rate limit instead of failing
+			assert.Contains(t, replies.Text(), `"tz":"Europe/Rome"`)
+			assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+		default:
+			// the daily quota is not refilled in time
+			assert.Contains(t, replies.Text(), `"message":"daily quota of 3 coordinates exceeded"`)
+		}
+	}
+	assert.NoError(t, pw.Close())
+	assert.False(t, replies.Scan())
+}
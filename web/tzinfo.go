@@ -0,0 +1,95 @@
+package web
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// embed the IANA database so the zone details are available in the scratch image
+	_ "time/tzdata"
+)
+
+// constant values for the time details
+const (
+	At             = "at"
+	Now            = "now"
+	Transitions    = "transitions"
+	MaxTransitions = 20
+)
+
+// parseInstant parses an instant in RFC 3339 format or as unix seconds,
+// an empty value or "now" returns the current time
+func parseInstant(val string) (time.Time, error) {
+	val = strings.TrimSpace(val)
+	if val == "" || strings.EqualFold(val, Now) {
+		return time.Now(), nil
+	}
+	if sec, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid value for %s, a RFC 3339 timestamp or unix seconds are required (eg. 2024-03-31T01:30:00Z)", At)
+	}
+	return t, nil
+}
+
+// zoneInfo returns the details of the zone tzName in effect at the given instant
+func zoneInfo(tzName string, at time.Time) (map[string]any, error) {
+	loc, err := time.LoadLocation(tzName)
+	if err != nil {
+		return nil, fmt.Errorf("time details not available for %s: %w", tzName, err)
+	}
+	local := at.In(loc)
+	abbreviation, offset := local.Zone()
+	return map[string]any{
+		"utc_offset_seconds": offset,
+		"is_dst":             local.IsDST(),
+		"abbreviation":       abbreviation,
+		"local_time":         local.Format(time.RFC3339),
+	}, nil
+}
+
+// zoneTransitions returns up to n offset transitions of the zone tzName following the given instant
+func zoneTransitions(tzName string, from time.Time, n int) ([]map[string]any, error) {
+	loc, err := time.LoadLocation(tzName)
+	if err != nil {
+		return nil, fmt.Errorf("time details not available for %s: %w", tzName, err)
+	}
+	transitions := make([]map[string]any, 0, n)
+	current := from.In(loc)
+	for len(transitions) < n {
+		// the end is zero when the zone is in effect forever
+		_, end := current.ZoneBounds()
+		if end.IsZero() {
+			break
+		}
+		next := end.In(loc)
+		oldAbbreviation, oldOffset := current.Zone()
+		newAbbreviation, newOffset := next.Zone()
+		current = next
+		// skip changes in the zone rules that do not affect the clock
+		if oldOffset == newOffset && oldAbbreviation == newAbbreviation {
+			continue
+		}
+		transitions = append(transitions, map[string]any{
+			At:                       end.UTC().Format(time.RFC3339),
+			"old_utc_offset_seconds": oldOffset,
+			"new_utc_offset_seconds": newOffset,
+			"old_abbreviation":       oldAbbreviation,
+			"new_abbreviation":       newAbbreviation,
+			"is_dst":                 next.IsDST(),
+		})
+	}
+	return transitions, nil
+}
+
+// parseTransitions parses the number of transitions requested
+func parseTransitions(val string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil || n < 1 || n > MaxTransitions {
+		return 0, fmt.Errorf("invalid value for %s, a number between 1 and %d is required", Transitions, MaxTransitions)
+	}
+	return n, nil
+}
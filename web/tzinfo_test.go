@@ -0,0 +1,130 @@
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseInstant(t *testing.T) {
+	tests := []struct {
+		val     string
+		want    time.Time
+		wantErr bool
+	}{
+		{"1711848600", time.Unix(1711848600, 0), false},
+		{"2024-03-31T01:30:00Z", time.Date(2024, 3, 31, 1, 30, 0, 0, time.UTC), false},
+		{"2024-03-31T03:30:00+02:00", time.Date(2024, 3, 31, 1, 30, 0, 0, time.UTC), false},
+		{"-86400", time.Unix(-86400, 0), false},
+		{"2024-03-31", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+		{"1.5", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.val, func(t *testing.T) {
+			got, err := parseInstant(tt.val)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "expected %v, got %v", tt.want, got)
+		})
+	}
+
+	// empty values and "now" default to the current time
+	for _, val := range []string{"", " ", "now", "NOW"} {
+		got, err := parseInstant(val)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), got, time.Second)
+	}
+}
+
+func Test_zoneInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		tz   string
+		at   time.Time
+		want map[string]any
+	}{
+		{
+			"winter time",
+			"Europe/Rome",
+			time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+			map[string]any{"utc_offset_seconds": 3600, "is_dst": false, "abbreviation": "CET", "local_time": "2024-01-15T13:00:00+01:00"},
+		},
+		{
+			"summer time",
+			"Europe/Rome",
+			time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC),
+			map[string]any{"utc_offset_seconds": 7200, "is_dst": true, "abbreviation": "CEST", "local_time": "2024-07-15T14:00:00+02:00"},
+		},
+		{
+			"negative offset",
+			"America/New_York",
+			time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+			map[string]any{"utc_offset_seconds": -18000, "is_dst": false, "abbreviation": "EST", "local_time": "2024-01-15T07:00:00-05:00"},
+		},
+		{
+			"ocean zone",
+			"Etc/GMT-3",
+			time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+			map[string]any{"utc_offset_seconds": 10800, "is_dst": false, "abbreviation": "+03", "local_time": "2024-01-15T15:00:00+03:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := zoneInfo(tt.tz, tt.at)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := zoneInfo("Mars/Olympus_Mons", time.Now())
+	assert.Error(t, err)
+}
+
+func Test_zoneTransitions(t *testing.T) {
+	from := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	got, err := zoneTransitions("Europe/Rome", from, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]any{
+		{"at": "2024-03-31T01:00:00Z", "old_utc_offset_seconds": 3600, "new_utc_offset_seconds": 7200, "old_abbreviation": "CET", "new_abbreviation": "CEST", "is_dst": true},
+		{"at": "2024-10-27T01:00:00Z", "old_utc_offset_seconds": 7200, "new_utc_offset_seconds": 3600, "old_abbreviation": "CEST", "new_abbreviation": "CET", "is_dst": false},
+		{"at": "2025-03-30T01:00:00Z", "old_utc_offset_seconds": 3600, "new_utc_offset_seconds": 7200, "old_abbreviation": "CET", "new_abbreviation": "CEST", "is_dst": true},
+	}, got)
+
+	// zones without daylight saving time have no transitions
+	got, err = zoneTransitions("Asia/Tokyo", from, 3)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	_, err = zoneTransitions("Mars/Olympus_Mons", from, 3)
+	assert.Error(t, err)
+}
+
+func Test_parseTransitions(t *testing.T) {
+	tests := []struct {
+		val     string
+		want    int
+		wantErr bool
+	}{
+		{"1", 1, false},
+		{" 3 ", 3, false},
+		{"20", 20, false},
+		{"0", 0, true},
+		{"-1", 0, true},
+		{"21", 0, true},
+		{"", 0, true},
+		{"three", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.val, func(t *testing.T) {
+			got, err := parseTransitions(tt.val)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}